@@ -0,0 +1,838 @@
+package ebnf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Issue describes a problem in a grammar found by static analysis
+type Issue struct {
+	Rule    string
+	Message string
+}
+
+// String returns the issue description
+func (i *Issue) String() string {
+	return fmt.Sprintf("rule %q: %s", i.Rule, i.Message)
+}
+
+// ruleID identifies a rule of a grammar
+type ruleID struct {
+	grammar *Grammar
+	name    string
+}
+
+// analyzer holds the rules reachable from a grammar, the nullable rules and the FIRST and FOLLOW sets
+type analyzer struct {
+	grammar  *Grammar
+	rules    []ruleID
+	patterns map[ruleID]Pattern
+	nullable map[ruleID]bool
+	first    map[ruleID]termSet
+	follow   map[ruleID]termSet
+	visiting map[Pattern]bool
+}
+
+// newAnalyzer collects the rules of a grammar and the rules of other grammars referenced by bound
+// references, and computes which rules are nullable
+func newAnalyzer(g *Grammar) *analyzer {
+	a := &analyzer{
+		grammar:  g,
+		rules:    []ruleID{},
+		patterns: map[ruleID]Pattern{},
+		nullable: map[ruleID]bool{},
+		visiting: map[Pattern]bool{},
+	}
+
+	for _, name := range g.names {
+		a.addRule(ruleID{grammar: g, name: name})
+	}
+
+	for i := 0; i < len(a.rules); i++ {
+		id := a.rules[i]
+
+		a.walk(a.patterns[id], func(p Pattern) {
+			if ref, ok := p.(*Reference); ok {
+				a.addRule(a.resolve(id.grammar, ref))
+			}
+		})
+	}
+
+	// The nullable rules are found by iterating until no more rules become nullable
+	for changed := true; changed; {
+		changed = false
+
+		for _, id := range a.rules {
+			if !a.nullable[id] && a.isNullable(id.grammar, a.patterns[id]) {
+				a.nullable[id] = true
+				changed = true
+			}
+		}
+	}
+
+	return a
+}
+
+// addRule adds a defined rule to the analyzer
+func (a *analyzer) addRule(id ruleID) {
+	if _, ok := a.patterns[id]; ok {
+		return
+	}
+
+	p, ok := id.grammar.rules[id.name]
+	if !ok {
+		return
+	}
+
+	a.rules = append(a.rules, id)
+	a.patterns[id] = p
+}
+
+// resolve returns the rule referenced from a rule of grammar g
+func (a *analyzer) resolve(g *Grammar, ref *Reference) ruleID {
+	if ref.Grammar != nil {
+		g = ref.Grammar
+	}
+
+	return ruleID{grammar: g, name: ref.Name}
+}
+
+// patternChildren returns the patterns matched by a pattern, references are not followed
+func patternChildren(p Pattern) []Pattern {
+	switch p := p.(type) {
+	case *Alternation:
+		return p.Patterns
+	case *Concatenation:
+		return p.Patterns
+	case *Repetition:
+		return []Pattern{p.Pattern}
+	case *Exception:
+		return []Pattern{p.MustMatch, p.Except}
+	case *And:
+		return []Pattern{p.Pattern}
+	case *Not:
+		return []Pattern{p.Pattern}
+	case *Recovery:
+		return []Pattern{p.Pattern, p.Sync}
+	case *Trivia:
+		return []Pattern{p.Pattern}
+	case *Binding:
+		return []Pattern{p.Pattern}
+	case *OperatorTable:
+		children := []Pattern{p.Operand}
+
+		for _, level := range p.Levels {
+			for _, op := range level.Operators {
+				children = append(children, op.Pattern)
+			}
+		}
+
+		return children
+	}
+
+	return nil
+}
+
+// walk calls f for each pattern reachable from p without following references, each pattern is
+// visited once
+func (a *analyzer) walk(p Pattern, f func(p Pattern)) {
+	visited := map[Pattern]bool{}
+
+	var visit func(p Pattern)
+	visit = func(p Pattern) {
+		if p == nil || visited[p] {
+			return
+		}
+
+		visited[p] = true
+
+		f(p)
+
+		for _, child := range patternChildren(p) {
+			visit(child)
+		}
+	}
+
+	visit(p)
+}
+
+// isNullable returns true if pattern p of a rule of grammar g can match without consuming input.
+// Unknown pattern types are assumed to consume input
+func (a *analyzer) isNullable(g *Grammar, p Pattern) bool {
+	// Patterns which contain themselves are not nullable through the cycle
+	if p == nil || a.visiting[p] {
+		return false
+	}
+
+	a.visiting[p] = true
+	defer delete(a.visiting, p)
+
+	switch p := p.(type) {
+	case *TerminalString:
+		return len(p.String) == 0
+	case *LiteralSet:
+		return p.root.terminal
+	case *Regexp:
+		return p.Regexp.MatchString("")
+	case *EOF, *And, *Not:
+		return true
+	case *Alternation:
+		for _, child := range p.Patterns {
+			if a.isNullable(g, child) {
+				return true
+			}
+		}
+
+		return false
+	case *Concatenation:
+		for _, child := range p.Patterns {
+			if !a.isNullable(g, child) {
+				return false
+			}
+		}
+
+		return true
+	case *Repetition:
+		return p.Min == 0 || a.isNullable(g, p.Pattern)
+	case *Exception:
+		return a.isNullable(g, p.MustMatch)
+	case *Recovery:
+		return a.isNullable(g, p.Pattern)
+	case *Trivia:
+		return a.isNullable(g, p.Pattern)
+	case *Binding:
+		return a.isNullable(g, p.Pattern)
+	case *OperatorTable:
+		return a.isNullable(g, p.Operand)
+	case *Reference:
+		return a.nullable[a.resolve(g, p)]
+	}
+
+	return false
+}
+
+// repetitionIssues reports repetitions of patterns which can match without consuming input
+func (a *analyzer) repetitionIssues() []*Issue {
+	g := a.grammar
+	issues := []*Issue{}
+
+	for _, name := range g.names {
+		id := ruleID{grammar: g, name: name}
+
+		a.walk(a.patterns[id], func(p Pattern) {
+			if rep, ok := p.(*Repetition); ok && rep.Max != 1 && a.isNullable(g, rep.Pattern) {
+				issues = append(issues, &Issue{
+					Rule:    name,
+					Message: "repetition of a pattern that can match empty input",
+				})
+			}
+		})
+	}
+
+	return issues
+}
+
+// Check analyzes the grammar for repetitions of patterns which can match without consuming input,
+// optional patterns are not reported. Such a repetition stops after the first empty iteration, which
+// is most likely not intended. ParseGrammar runs the check and stores the issues in Grammar.Warnings,
+// grammars built with Rule should be checked after all rules are defined. See Analyze for a complete
+// analysis
+func (g *Grammar) Check() []*Issue {
+	return newAnalyzer(g).repetitionIssues()
+}
+
+// unnamedGroup describes character groups without a name in FIRST and FOLLOW sets
+const unnamedGroup = "character group"
+
+// Analysis holds the result of the static analysis of a grammar. Terminals are described the same way
+// as the expectations of a ParseError: quoted terminal strings and the names of character groups,
+// character groups without a name are described as "character group"
+type Analysis struct {
+	// Nullable holds the rules which can match without consuming input
+	Nullable map[string]bool
+	// First holds the terminals a match of a rule can start with
+	First map[string][]string
+	// Follow holds the terminals that can follow a match of a rule, "end of input" follows the start rule
+	Follow map[string][]string
+	// Issues holds unreachable rules, undefined references, left recursion, shadowed alternatives and
+	// repetitions of patterns that can match empty input
+	Issues []*Issue
+}
+
+// termSet is a set of terminal descriptions
+type termSet map[string]bool
+
+// addAll adds the terminals of another set, returns true if terminals were added
+func (s termSet) addAll(other termSet) bool {
+	changed := false
+
+	for term := range other {
+		if !s[term] {
+			s[term] = true
+			changed = true
+		}
+	}
+
+	return changed
+}
+
+// sorted returns the terminals in sorted order
+func (s termSet) sorted() []string {
+	terms := make([]string, 0, len(s))
+
+	for term := range s {
+		terms = append(terms, term)
+	}
+
+	sort.Strings(terms)
+
+	return terms
+}
+
+// computeFirst computes the FIRST sets of the rules
+func (a *analyzer) computeFirst() {
+	a.first = map[ruleID]termSet{}
+
+	for _, id := range a.rules {
+		a.first[id] = termSet{}
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, id := range a.rules {
+			if a.first[id].addAll(a.firstOf(id.grammar, a.patterns[id])) {
+				changed = true
+			}
+		}
+	}
+}
+
+// firstOf returns the terminals a match of pattern p of a rule of grammar g can start with
+func (a *analyzer) firstOf(g *Grammar, p Pattern) termSet {
+	first := termSet{}
+
+	if p == nil || a.visiting[p] {
+		return first
+	}
+
+	a.visiting[p] = true
+	defer delete(a.visiting, p)
+
+	switch p := p.(type) {
+	case *TerminalString:
+		if len(p.String) > 0 {
+			first[strconv.Quote(p.String)] = true
+		}
+	case *CharacterGroup:
+		if p.Name != "" {
+			first[p.Name] = true
+		} else {
+			first[unnamedGroup] = true
+		}
+	case *LiteralSet:
+		for _, literal := range p.Literals {
+			if len(literal) > 0 {
+				first[strconv.Quote(literal)] = true
+			}
+		}
+	case *Alternation:
+		for _, child := range p.Patterns {
+			first.addAll(a.firstOf(g, child))
+		}
+	case *Concatenation:
+		for _, child := range p.Patterns {
+			first.addAll(a.firstOf(g, child))
+
+			if !a.isNullable(g, child) {
+				break
+			}
+		}
+	case *Repetition:
+		first.addAll(a.firstOf(g, p.Pattern))
+	case *Exception:
+		first.addAll(a.firstOf(g, p.MustMatch))
+	case *Recovery, *Trivia, *Binding:
+		first.addAll(a.firstOf(g, patternChildren(p)[0]))
+	case *OperatorTable:
+		first.addAll(a.firstOf(g, p.Operand))
+
+		for _, level := range p.Levels {
+			if level.Kind == Prefix {
+				for _, op := range level.Operators {
+					first.addAll(a.firstOf(g, op.Pattern))
+				}
+			}
+		}
+	case *Reference:
+		first.addAll(a.first[a.resolve(g, p)])
+	}
+
+	return first
+}
+
+// follower holds the terminals that can follow a pattern, toEnd is true if the pattern can be
+// followed by the end of the rule
+type follower struct {
+	terms termSet
+	toEnd bool
+}
+
+// computeFollow computes the FOLLOW sets of the rules
+func (a *analyzer) computeFollow() {
+	a.follow = map[ruleID]termSet{}
+
+	for _, id := range a.rules {
+		a.follow[id] = termSet{}
+	}
+
+	if name, err := a.grammar.startName(); err == nil {
+		a.follow[ruleID{grammar: a.grammar, name: name}] = termSet{"end of input": true}
+	}
+
+	for changed := true; changed; {
+		changed = false
+
+		for _, id := range a.rules {
+			if a.followIn(id, a.patterns[id], follower{terms: termSet{}, toEnd: true}, map[Pattern]bool{}) {
+				changed = true
+			}
+		}
+	}
+}
+
+// followIn adds the terminals that can follow the references in pattern p of rule id, next holds
+// the terminals that can follow p. Returns true if terminals were added
+func (a *analyzer) followIn(id ruleID, p Pattern, next follower, visited map[Pattern]bool) bool {
+	if p == nil || visited[p] {
+		return false
+	}
+
+	visited[p] = true
+	defer delete(visited, p)
+
+	changed := false
+	g := id.grammar
+
+	switch p := p.(type) {
+	case *Reference:
+		follow, ok := a.follow[a.resolve(g, p)]
+		if ok {
+			changed = follow.addAll(next.terms)
+
+			if next.toEnd && follow.addAll(a.follow[id]) {
+				changed = true
+			}
+		}
+	case *Alternation:
+		for _, child := range p.Patterns {
+			if a.followIn(id, child, next, visited) {
+				changed = true
+			}
+		}
+	case *Concatenation:
+		// The terminals following a child are the FIRST terminals of the children after it
+		for i := len(p.Patterns) - 1; i >= 0; i-- {
+			child := p.Patterns[i]
+
+			if a.followIn(id, child, next, visited) {
+				changed = true
+			}
+
+			terms := a.firstOf(g, child)
+
+			if a.isNullable(g, child) {
+				terms.addAll(next.terms)
+				next = follower{terms: terms, toEnd: next.toEnd}
+			} else {
+				next = follower{terms: terms}
+			}
+		}
+	case *Repetition:
+		if p.Max != 1 {
+			terms := a.firstOf(g, p.Pattern)
+			terms.addAll(next.terms)
+			next = follower{terms: terms, toEnd: next.toEnd}
+		}
+
+		changed = a.followIn(id, p.Pattern, next, visited)
+	case *Exception:
+		changed = a.followIn(id, p.MustMatch, next, visited)
+	case *Recovery:
+		changed = a.followIn(id, p.Pattern, next, visited)
+	case *Trivia:
+		changed = a.followIn(id, p.Pattern, next, visited)
+	case *Binding:
+		changed = a.followIn(id, p.Pattern, next, visited)
+	case *OperatorTable:
+		// Operands can be followed by any operator
+		terms := termSet{}
+		terms.addAll(next.terms)
+
+		for _, level := range p.Levels {
+			for _, op := range level.Operators {
+				terms.addAll(a.firstOf(g, op.Pattern))
+			}
+		}
+
+		for _, child := range patternChildren(p) {
+			if a.followIn(id, child, follower{terms: terms, toEnd: next.toEnd}, visited) {
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// references returns the references in a pattern, if left is true only the references which can
+// be matched before input is consumed are returned
+func (a *analyzer) references(g *Grammar, p Pattern, left bool) []*Reference {
+	refs := []*Reference{}
+	visited := map[Pattern]bool{}
+
+	var visit func(p Pattern)
+	visit = func(p Pattern) {
+		if p == nil || visited[p] {
+			return
+		}
+
+		visited[p] = true
+
+		switch p := p.(type) {
+		case *Reference:
+			refs = append(refs, p)
+		case *Concatenation:
+			for _, child := range p.Patterns {
+				visit(child)
+
+				if left && !a.isNullable(g, child) {
+					break
+				}
+			}
+		case *Recovery:
+			visit(p.Pattern)
+
+			if !left {
+				visit(p.Sync)
+			}
+		case *OperatorTable:
+			visit(p.Operand)
+
+			for _, level := range p.Levels {
+				if level.Kind == Prefix || !left {
+					for _, op := range level.Operators {
+						visit(op.Pattern)
+					}
+				}
+			}
+		default:
+			for _, child := range patternChildren(p) {
+				visit(child)
+			}
+		}
+	}
+
+	visit(p)
+
+	return refs
+}
+
+// referenceIssues reports references to undefined rules and rules which can not be reached from the
+// start rule
+func (a *analyzer) referenceIssues() []*Issue {
+	g := a.grammar
+	issues := []*Issue{}
+
+	for _, name := range g.names {
+		for _, ref := range a.references(g, g.rules[name], false) {
+			if _, ok := a.patterns[a.resolve(g, ref)]; !ok {
+				issues = append(issues, &Issue{
+					Rule:    name,
+					Message: fmt.Sprintf("reference to undefined rule %q", ref.Name),
+				})
+			}
+		}
+	}
+
+	start, err := g.startName()
+	if err != nil {
+		return issues
+	}
+
+	reachable := map[ruleID]bool{}
+	queue := []ruleID{{grammar: g, name: start}}
+
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+
+		if reachable[id] {
+			continue
+		}
+
+		reachable[id] = true
+
+		for _, ref := range a.references(id.grammar, a.patterns[id], false) {
+			queue = append(queue, a.resolve(id.grammar, ref))
+		}
+	}
+
+	for _, name := range g.names {
+		if !reachable[ruleID{grammar: g, name: name}] {
+			issues = append(issues, &Issue{
+				Rule:    name,
+				Message: "rule can not be reached from the start rule",
+			})
+		}
+	}
+
+	return issues
+}
+
+// leftRecursionIssues reports each group of mutually left recursive rules once, using Tarjan's
+// strongly connected components algorithm on the references which are matched before input is consumed
+func (a *analyzer) leftRecursionIssues() []*Issue {
+	issues := []*Issue{}
+	index := map[ruleID]int{}
+	lowLink := map[ruleID]int{}
+	onStack := map[ruleID]bool{}
+	stack := []ruleID{}
+
+	var connect func(id ruleID)
+	connect = func(id ruleID) {
+		index[id] = len(index)
+		lowLink[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+
+		selfRecursive := false
+
+		for _, ref := range a.references(id.grammar, a.patterns[id], true) {
+			next := a.resolve(id.grammar, ref)
+			if _, ok := a.patterns[next]; !ok {
+				continue
+			}
+
+			if next == id {
+				selfRecursive = true
+			}
+
+			if _, ok := index[next]; !ok {
+				connect(next)
+
+				if lowLink[next] < lowLink[id] {
+					lowLink[id] = lowLink[next]
+				}
+			} else if onStack[next] && index[next] < lowLink[id] {
+				lowLink[id] = index[next]
+			}
+		}
+
+		if lowLink[id] != index[id] {
+			return
+		}
+
+		component := []string{}
+
+		for {
+			member := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[member] = false
+			component = append(component, member.name)
+
+			if member == id {
+				break
+			}
+		}
+
+		if len(component) > 1 || selfRecursive {
+			sort.Strings(component)
+
+			issues = append(issues, &Issue{
+				Rule:    component[0],
+				Message: fmt.Sprintf("left recursion through %s", strings.Join(component, ", ")),
+			})
+		}
+	}
+
+	for _, id := range a.rules {
+		if _, ok := index[id]; !ok && id.grammar == a.grammar {
+			connect(id)
+		}
+	}
+
+	return issues
+}
+
+// literalPrefix returns the literal every match of a pattern starts with
+func literalPrefix(p Pattern) string {
+	switch p := p.(type) {
+	case *TerminalString:
+		return p.String
+	case *Concatenation:
+		prefix := ""
+
+		for _, child := range p.Patterns {
+			terminal, ok := child.(*TerminalString)
+			if !ok {
+				return prefix + literalPrefix(child)
+			}
+
+			prefix += terminal.String
+		}
+
+		return prefix
+	}
+
+	return ""
+}
+
+// groupClass returns the class of the runes matched by a character group, returns nil if the group is
+// not declared as a character class
+func groupClass(g *CharacterGroup) *CharacterClass {
+	if g.Class == nil || !g.Reversed {
+		return g.Class
+	}
+
+	return g.Class.Negate()
+}
+
+// shadowIssues reports alternatives which can never match because an earlier alternative matches
+// whenever they would: an earlier alternative which matches any input, an earlier terminal string
+// which is a prefix of every match of the alternative, or an earlier character group which contains
+// the single character terminal or all characters of the character group of the alternative
+func (a *analyzer) shadowIssues() []*Issue {
+	g := a.grammar
+	issues := []*Issue{}
+
+	for _, name := range g.names {
+		a.walk(g.rules[name], func(p Pattern) {
+			alt, ok := p.(*Alternation)
+			if !ok {
+				return
+			}
+
+			for j := 1; j < len(alt.Patterns); j++ {
+				for i := 0; i < j; i++ {
+					if a.shadows(g, alt.Patterns[i], alt.Patterns[j]) {
+						issues = append(issues, &Issue{
+							Rule:    name,
+							Message: fmt.Sprintf("alternative %d is shadowed by alternative %d", j+1, i+1),
+						})
+
+						break
+					}
+				}
+			}
+		})
+	}
+
+	return issues
+}
+
+// alwaysMatches returns true if pattern p of a rule of grammar g matches any input. Unlike nullable
+// patterns, lookaheads and end of input can fail without consuming input
+func (a *analyzer) alwaysMatches(g *Grammar, p Pattern) bool {
+	if p == nil || a.visiting[p] {
+		return false
+	}
+
+	a.visiting[p] = true
+	defer delete(a.visiting, p)
+
+	switch p := p.(type) {
+	case *TerminalString:
+		return len(p.String) == 0
+	case *LiteralSet:
+		return p.root.terminal
+	case *Regexp:
+		return p.Regexp.MatchString("")
+	case *Alternation:
+		for _, child := range p.Patterns {
+			if a.alwaysMatches(g, child) {
+				return true
+			}
+		}
+
+		return false
+	case *Concatenation:
+		for _, child := range p.Patterns {
+			if !a.alwaysMatches(g, child) {
+				return false
+			}
+		}
+
+		return true
+	case *Repetition:
+		return p.Min == 0 || a.alwaysMatches(g, p.Pattern)
+	case *Recovery:
+		return a.alwaysMatches(g, p.Pattern)
+	case *Trivia:
+		return a.alwaysMatches(g, p.Pattern)
+	case *Binding:
+		return a.alwaysMatches(g, p.Pattern)
+	case *OperatorTable:
+		return a.alwaysMatches(g, p.Operand)
+	case *Reference:
+		id := a.resolve(g, p)
+		return a.alwaysMatches(id.grammar, a.patterns[id])
+	}
+
+	return false
+}
+
+// shadows returns true if earlier always matches when later matches
+func (a *analyzer) shadows(g *Grammar, earlier Pattern, later Pattern) bool {
+	if a.alwaysMatches(g, earlier) {
+		return true
+	}
+
+	switch earlier := earlier.(type) {
+	case *TerminalString:
+		return strings.HasPrefix(literalPrefix(later), earlier.String)
+	case *CharacterGroup:
+		switch later := later.(type) {
+		case *TerminalString:
+			runes := []rune(later.String)
+			return len(runes) == 1 && earlier.Group(runes[0]) != earlier.Reversed
+		case *CharacterGroup:
+			earlierClass, laterClass := groupClass(earlier), groupClass(later)
+			return earlierClass != nil && laterClass != nil && len(laterClass.Difference(earlierClass).ranges) == 0
+		}
+	}
+
+	return false
+}
+
+// Analyze computes the nullable rules and the FIRST and FOLLOW sets of the rules of the grammar
+// and reports issues in the grammar
+func (g *Grammar) Analyze() *Analysis {
+	a := newAnalyzer(g)
+	a.computeFirst()
+	a.computeFollow()
+
+	analysis := &Analysis{
+		Nullable: map[string]bool{},
+		First:    map[string][]string{},
+		Follow:   map[string][]string{},
+		Issues:   []*Issue{},
+	}
+
+	for _, name := range g.names {
+		id := ruleID{grammar: g, name: name}
+
+		analysis.Nullable[name] = a.nullable[id]
+		analysis.First[name] = a.first[id].sorted()
+		analysis.Follow[name] = a.follow[id].sorted()
+	}
+
+	analysis.Issues = append(analysis.Issues, a.referenceIssues()...)
+	analysis.Issues = append(analysis.Issues, a.leftRecursionIssues()...)
+	analysis.Issues = append(analysis.Issues, a.shadowIssues()...)
+	analysis.Issues = append(analysis.Issues, a.repetitionIssues()...)
+
+	return analysis
+}
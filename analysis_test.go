@@ -0,0 +1,158 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestGrammarCheck(t *testing.T) {
+	grammar := NewGrammar().
+		Rule("list", NewConcatenation([]Pattern{NewAny(Ref("item"), nil), NewTerminalString(";", nil)}, nil)).
+		Rule("item", NewConcatenation([]Pattern{NewOptional(NewTerminalString("x", nil), nil), Ref("ws")}, nil)).
+		Rule("ws", NewAny(NewTerminalString(" ", nil), nil)).
+		Rule("ok", NewAny(NewConcatenation([]Pattern{NewTerminalString("y", nil), Ref("ws")}, nil), nil)).
+		Rule("nested", NewAny(NewOptional(Ref("ok"), nil), nil))
+
+	issues := grammar.Check()
+
+	rules := []string{}
+	for _, issue := range issues {
+		rules = append(rules, issue.Rule)
+	}
+
+	if strings.Join(rules, ",") != "list,nested" {
+		t.Fatalf("unexpected issues %v", issues)
+	}
+
+	if issues[0].String() != `rule "list": repetition of a pattern that can match empty input` {
+		t.Errorf("unexpected issue %q", issues[0].String())
+	}
+
+	// ParseGrammar runs the check, the grammar can still be used
+	grammar, err := ParseGrammar(strings.NewReader(`
+		list = { item }, ";" ;
+		item = [ "x" ], ws ;
+		ws = { " " } ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if len(grammar.Warnings) != 1 || grammar.Warnings[0].String() != `rule "list": repetition of a pattern that can match empty input` {
+		t.Errorf("unexpected warnings %v", grammar.Warnings)
+	}
+
+	reader, _ := NewReader(strings.NewReader("x x;"))
+
+	result, err := grammar.Match(reader)
+	if err != nil || !result.Match || !reader.Finished() {
+		t.Errorf("expected list to match all input")
+	}
+}
+
+func TestGrammarAnalyze(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		expression = expression, "+", term | term ;
+		term = [ "-" ], ( number | "(", expression, ")" ) ;
+		number = digit, { digit } ;
+		digit = "0" | "1" | "2" ;
+		unused = "x" ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	analysis := grammar.Analyze()
+
+	if analysis.Nullable["expression"] || analysis.Nullable["number"] {
+		t.Errorf("expected no nullable rules, got %v", analysis.Nullable)
+	}
+
+	if first := strings.Join(analysis.First["term"], " "); first != `"(" "-" "0" "1" "2"` {
+		t.Errorf("unexpected FIRST set of term: %v", first)
+	}
+
+	if follow := strings.Join(analysis.Follow["term"], " "); follow != `")" "+" end of input` {
+		t.Errorf("unexpected FOLLOW set of term: %v", follow)
+	}
+
+	if follow := strings.Join(analysis.Follow["digit"], " "); follow != `")" "+" "0" "1" "2" end of input` {
+		t.Errorf("unexpected FOLLOW set of digit: %v", follow)
+	}
+
+	// Character groups without a name are described by a fallback
+	grammar.Rule("digit", NewCharacterGroup(unicode.IsDigit, false, nil))
+
+	if first := strings.Join(grammar.Analyze().First["number"], " "); first != "character group" {
+		t.Errorf("unexpected FIRST set of number: %v", first)
+	}
+
+	issues := []string{}
+	for _, issue := range analysis.Issues {
+		issues = append(issues, issue.String())
+	}
+
+	expected := []string{
+		`rule "unused": rule can not be reached from the start rule`,
+		`rule "expression": left recursion through expression`,
+	}
+
+	if strings.Join(issues, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected issues %v", issues)
+	}
+}
+
+func TestGrammarAnalyzeIssues(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		a = b | "x" ;
+		b = c, "y" | "k" | "key" | ( "k", "ey" ) | letter | ? special ? ;
+		c = [ "z" ], a ;
+		letter = { "l" } | "m" ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	grammar.Rule("letter", NewAlternation([]Pattern{NewCharacterRange('a', 'z', false, nil), NewTerminalString("q", nil)}, nil))
+
+	issues := []string{}
+	for _, issue := range grammar.Analyze().Issues {
+		issues = append(issues, issue.String())
+	}
+
+	expected := []string{
+		`rule "b": reference to undefined rule "special"`,
+		`rule "a": left recursion through a, b, c`,
+		`rule "b": alternative 3 is shadowed by alternative 2`,
+		`rule "b": alternative 4 is shadowed by alternative 2`,
+		`rule "letter": alternative 2 is shadowed by alternative 1`,
+	}
+
+	if strings.Join(issues, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected issues:\n%v", strings.Join(issues, "\n"))
+	}
+}
+
+func TestGrammarAnalyzeShadowLookahead(t *testing.T) {
+	grammar := NewGrammar().
+		Rule("end", NewAlternation([]Pattern{NewEOF(nil), NewTerminalString(";", nil)}, nil)).
+		Rule("not", NewAlternation([]Pattern{NewNot(NewTerminalString("x", nil), nil), NewTerminalString("x", nil)}, nil)).
+		Rule("optional", NewAlternation([]Pattern{NewConcatenation([]Pattern{Ref("empty"), NewOptional(NewTerminalString("a", nil), nil)}, nil), NewTerminalString("b", nil)}, nil)).
+		Rule("empty", NewTerminalString("", nil))
+
+	issues := []string{}
+	for _, issue := range grammar.Analyze().Issues {
+		if strings.Contains(issue.Message, "shadowed") {
+			issues = append(issues, issue.String())
+		}
+	}
+
+	expected := []string{
+		`rule "optional": alternative 2 is shadowed by alternative 1`,
+	}
+
+	if strings.Join(issues, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("unexpected issues:\n%v", strings.Join(issues, "\n"))
+	}
+}
@@ -0,0 +1,337 @@
+package ebnf
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// matchResultType is the type of a *MatchResult field
+var matchResultType = reflect.TypeOf(&MatchResult{})
+
+// bindType returns the struct type of a binding value, panics if v is not a struct or a pointer to a struct
+func bindType(v interface{}) (reflect.Type, bool) {
+	t := reflect.TypeOf(v)
+
+	if t != nil && t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct {
+		return t.Elem(), true
+	}
+
+	if t != nil && t.Kind() == reflect.Struct {
+		return t, false
+	}
+
+	panic(fmt.Sprintf("ebnf: can not bind to %v, expected a struct or a pointer to a struct", t))
+}
+
+// Bind returns a transform that sets MatchResult.Result of a match to a new value of the type of v,
+// which must be a struct or a pointer to a struct. The exported fields of the struct with an ebnf tag
+// are filled from the match tree:
+//
+//	Name  string        `ebnf:"identifier"`  the first result of rule identifier
+//	Items []*Item       `ebnf:"item"`        all results of rule item
+//	Value string        `ebnf:"@2"`          the third child of the result
+//	Digit int           `ebnf:"@1.0"`        the first child of the second child of the result
+//
+// Rule results are searched in the children of the result, without searching the results of other
+// rules, the inner result of a rule (see MatchResult.Inner) is searched for a rule result. A child
+// result whose MatchResult.Result is assignable to the field (for instance a result of a rule that
+// is bound itself) is assigned to the field. Otherwise string, bool, integer and float fields are
+// parsed from the matched input (integers in base 10), struct and pointer to struct fields are
+// filled from the child result, slice fields are filled with the children of an indexed result,
+// *MatchResult fields hold the result and interface{} fields hold MatchResult.Result. Bound structs
+// are converted to a pointer to the struct and the other way around. Fields of results that are not
+// found are not set. Bind panics if v is not a struct or a pointer to a struct
+func Bind(v interface{}) TransformFunction {
+	t, pointer := bindType(v)
+
+	return func(m *MatchResult, r *Reader) error {
+		if !m.Match {
+			return nil
+		}
+
+		value := reflect.New(t)
+
+		err := bindStruct(m, value.Elem())
+		if err != nil {
+			return err
+		}
+
+		if pointer {
+			m.Result = value.Interface()
+		} else {
+			m.Result = value.Elem().Interface()
+		}
+
+		return nil
+	}
+}
+
+// Binding pattern, binds the match result of a pattern to a struct (see Bind), the transform is called
+// after binding
+type Binding struct {
+	BaseTransformer
+	Pattern Pattern
+	bind    TransformFunction
+}
+
+// NewBinding creates a new binding of a pattern to the type of v, panics if v is not a struct or a
+// pointer to a struct
+func NewBinding(p Pattern, v interface{}, t TransformFunction) *Binding {
+	return &Binding{
+		BaseTransformer: BaseTransformer{
+			T: t,
+		},
+		Pattern: p,
+		bind:    Bind(v),
+	}
+}
+
+// Match binding pattern, MatchResult.Result will contain the bound struct
+func (b *Binding) Match(r *Reader) (*MatchResult, error) {
+	result, err := b.Pattern.Match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.bind(result, r)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.Transform(result, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Bind binds a rule of the grammar to the type of v (see Bind), the rule is replaced by a binding of
+// the pattern of the rule
+func (g *Grammar) Bind(name string, v interface{}) error {
+	p, ok := g.rules[name]
+	if !ok {
+		return fmt.Errorf("undefined rule %q", name)
+	}
+
+	t := reflect.TypeOf(v)
+	if t == nil || (t.Kind() != reflect.Struct && (t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Struct)) {
+		return fmt.Errorf("can not bind rule %q to %v, expected a struct or a pointer to a struct", name, t)
+	}
+
+	g.Rule(name, NewBinding(p, v, nil))
+
+	return nil
+}
+
+// ruleResults returns the results of a rule in the children of a result, results of other rules are
+// not searched except for their inner results
+func ruleResults(m *MatchResult, name string, results []*MatchResult) []*MatchResult {
+	for _, child := range children(m) {
+		if child == nil || !child.Match {
+			continue
+		}
+
+		if child.Rule == name {
+			results = append(results, child)
+		} else if child.Rule == "" || child.Inner != nil {
+			results = ruleResults(child, name, results)
+		}
+	}
+
+	return results
+}
+
+// indexedResult returns the result of an index path like @1.0, indices refer to the children of the
+// innermost result of a rule
+func indexedResult(m *MatchResult, path string) (*MatchResult, error) {
+	for _, index := range strings.Split(path, ".") {
+		for m.Inner != nil {
+			m = m.Inner
+		}
+
+		i, err := strconv.Atoi(index)
+		if err != nil {
+			return nil, fmt.Errorf("invalid index %q", index)
+		}
+
+		results := children(m)
+		if i < 0 || i >= len(results) {
+			return nil, fmt.Errorf("no child at index %d", i)
+		}
+
+		m = results[i]
+	}
+
+	return m, nil
+}
+
+// bindStruct fills the tagged fields of a struct value from a match result
+func bindStruct(m *MatchResult, v reflect.Value) error {
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		tag, ok := field.Tag.Lookup("ebnf")
+		if !ok || field.PkgPath != "" {
+			continue
+		}
+
+		var results []*MatchResult
+
+		if strings.HasPrefix(tag, "@") {
+			result, err := indexedResult(m, tag[1:])
+			if err != nil {
+				return fmt.Errorf("field %s: %v", field.Name, err)
+			}
+
+			results = []*MatchResult{result}
+
+			if field.Type.Kind() == reflect.Slice && !assignable(result, field.Type) {
+				results = children(result)
+			}
+		} else {
+			results = ruleResults(m, tag, nil)
+		}
+
+		if len(results) == 0 {
+			continue
+		}
+
+		var err error
+
+		if field.Type.Kind() == reflect.Slice && !assignable(results[0], field.Type) {
+			slice := reflect.MakeSlice(field.Type, 0, len(results))
+
+			for _, result := range results {
+				elem := reflect.New(field.Type.Elem()).Elem()
+
+				err = bindValue(result, elem)
+				if err != nil {
+					break
+				}
+
+				slice = reflect.Append(slice, elem)
+			}
+
+			v.Field(i).Set(slice)
+		} else {
+			err = bindValue(results[0], v.Field(i))
+		}
+
+		if err != nil {
+			return fmt.Errorf("field %s: %v", field.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// assignable returns true if the result of a match result can be assigned to a type
+func assignable(m *MatchResult, t reflect.Type) bool {
+	return m.Result != nil && reflect.TypeOf(m.Result).AssignableTo(t)
+}
+
+// text returns the string result or the matched input of a match result
+func text(m *MatchResult) string {
+	if s, ok := m.Result.(string); ok {
+		return s
+	}
+
+	if m.BeginPos == nil || m.EndPos == nil || m.BeginPos.reader == nil {
+		return ""
+	}
+
+	return m.BeginPos.reader.StringFromResult(m)
+}
+
+// bindValue sets a value from a match result
+func bindValue(m *MatchResult, v reflect.Value) error {
+	t := v.Type()
+
+	if t == matchResultType {
+		v.Set(reflect.ValueOf(m))
+		return nil
+	}
+
+	if assignable(m, t) {
+		v.Set(reflect.ValueOf(m.Result))
+		return nil
+	}
+
+	// Results bound to a struct can be assigned to a pointer to the struct and vice versa
+	if m.Result != nil {
+		result := reflect.ValueOf(m.Result)
+
+		if t.Kind() == reflect.Ptr && result.Type().AssignableTo(t.Elem()) {
+			elem := reflect.New(t.Elem())
+			elem.Elem().Set(result)
+			v.Set(elem)
+			return nil
+		}
+
+		if result.Kind() == reflect.Ptr && !result.IsNil() && result.Type().Elem().AssignableTo(t) {
+			v.Set(result.Elem())
+			return nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		if m.Result != nil {
+			return fmt.Errorf("can not assign %T to %v", m.Result, t)
+		}
+	case reflect.String:
+		v.SetString(text(m))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text(m))
+		if err != nil {
+			return err
+		}
+
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(text(m), 10, t.Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(text(m), 10, t.Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text(m), t.Bits())
+		if err != nil {
+			return err
+		}
+
+		v.SetFloat(f)
+	case reflect.Struct:
+		return bindStruct(m, v)
+	case reflect.Ptr:
+		if t.Elem().Kind() != reflect.Struct {
+			return fmt.Errorf("can not bind to %v", t)
+		}
+
+		elem := reflect.New(t.Elem())
+
+		err := bindStruct(m, elem.Elem())
+		if err != nil {
+			return err
+		}
+
+		v.Set(elem)
+	default:
+		return fmt.Errorf("can not bind to %v", t)
+	}
+
+	return nil
+}
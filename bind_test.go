@@ -0,0 +1,219 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+)
+
+type boundValue struct {
+	Number *MatchResult `ebnf:"number"`
+	Text   string       `ebnf:"@0"`
+}
+
+type boundAssignment struct {
+	Identifier string      `ebnf:"identifier"`
+	Value      *boundValue `ebnf:"@2"`
+	ignored    string      `ebnf:"identifier"`
+}
+
+type boundProgram struct {
+	Assignments []*boundAssignment `ebnf:"assignment"`
+	Identifiers []string           `ebnf:"identifier"`
+}
+
+func TestGrammarBind(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		program = { assignment, ";" } ;
+		assignment = identifier, ":=", ( number | identifier ) ;
+		identifier = letter, { letter | digit } ;
+		number = [ "-" ], digit, { digit } ;
+		letter = "a" | "b" | "c" | "x" | "y" | "z" ;
+		digit = "0" | "1" | "2" | "3" ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if err = grammar.Bind("program", &boundProgram{}); err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if err = grammar.Bind("assignment", boundAssignment{}); err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if err = grammar.Bind("missing", &boundProgram{}); err == nil {
+		t.Errorf("expected error for undefined rule")
+	}
+
+	if err = grammar.Bind("number", 12); err == nil {
+		t.Errorf("expected error for binding to an int")
+	}
+
+	reader, _ := NewReader(strings.NewReader("abc:=-12;x:=y;"))
+
+	result, err := grammar.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() {
+		t.Fatalf("expected program to match all input")
+	}
+
+	program, ok := result.Result.(*boundProgram)
+	if !ok {
+		t.Fatalf("expected *boundProgram, got %T", result.Result)
+	}
+
+	// Assignments are bound to struct values and converted to pointers
+	if len(program.Assignments) != 2 || program.Assignments[0].Identifier != "abc" || program.Assignments[1].Identifier != "x" {
+		t.Fatalf("unexpected assignments %v", program.Assignments)
+	}
+
+	// Identifiers in assignments are not searched
+	if len(program.Identifiers) != 0 {
+		t.Errorf("expected no identifiers, got %v", program.Identifiers)
+	}
+
+	value := program.Assignments[0].Value
+	if value.Text != "-" || value.Number != nil {
+		t.Errorf("unexpected value %+v", value)
+	}
+
+	if program.Assignments[0].ignored != "" {
+		t.Errorf("expected unexported field to be ignored")
+	}
+}
+
+type boundPoint struct {
+	X     int     `ebnf:"@1"`
+	Y     float64 `ebnf:"@3"`
+	Label string  `ebnf:"@5.1"`
+	Name  interface{}
+}
+
+type boundPath struct {
+	Points []boundPoint `ebnf:"@0"`
+	Tags   []string     `ebnf:"@1"`
+}
+
+func TestBind(t *testing.T) {
+	digits := NewRepetition(NewCharacterRange('0', '9', false, nil), 1, 0, nil)
+	label := NewRepetition(NewCharacterRange('a', 'z', false, nil), 1, 0, func(m *MatchResult, r *Reader) error {
+		if m.Match {
+			m.Result = strings.ToUpper(r.StringFromResult(m))
+		}
+		return nil
+	})
+
+	point := NewConcatenation([]Pattern{
+		NewTerminalString("(", nil), digits, NewTerminalString(",", nil), digits, NewTerminalString(")", nil),
+		NewConcatenation([]Pattern{NewTerminalString(":", nil), label}, nil),
+	}, Bind(boundPoint{}))
+
+	path := NewConcatenation([]Pattern{
+		NewRepetition(point, 1, 0, nil),
+		NewAny(NewCharacterRange('a', 'z', false, nil), nil),
+	}, Bind(&boundPath{}))
+
+	reader, _ := NewReader(strings.NewReader("(1,2):a(30,4):bc"))
+
+	result, err := path.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	p, ok := result.Result.(*boundPath)
+	if !result.Match || !ok {
+		t.Fatalf("expected *boundPath, got %T", result.Result)
+	}
+
+	if len(p.Points) != 2 || p.Points[1].X != 30 || p.Points[1].Y != 4 {
+		t.Fatalf("unexpected points %+v", p.Points)
+	}
+
+	// The transform of the label is called before binding
+	if p.Points[0].Label != "A" || p.Points[1].Label != "BC" {
+		t.Errorf("unexpected labels %q %q", p.Points[0].Label, p.Points[1].Label)
+	}
+
+	if len(p.Tags) != 0 {
+		t.Errorf("expected no tags, got %v", p.Tags)
+	}
+
+	bad := NewConcatenation([]Pattern{digits}, Bind(&struct {
+		Value int `ebnf:"@1"`
+	}{}))
+
+	reader, _ = NewReader(strings.NewReader("12"))
+	if _, err = bad.Match(reader); err == nil || err.Error() != "field Value: no child at index 1" {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	overflow := NewBinding(NewConcatenation([]Pattern{digits}, nil), &struct {
+		Value int8 `ebnf:"@0"`
+	}{}, nil)
+
+	reader, _ = NewReader(strings.NewReader("1234"))
+	if _, err = overflow.Match(reader); err == nil {
+		t.Errorf("expected error for integer out of range")
+	}
+
+	// Integers are decimal, leading zeros do not denote octal numbers
+	number := NewConcatenation([]Pattern{digits}, Bind(&struct {
+		Value int  `ebnf:"@0"`
+		Count uint `ebnf:"@0"`
+	}{}))
+
+	reader, _ = NewReader(strings.NewReader("010"))
+
+	result, err = number.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if n := result.Result.(*struct {
+		Value int  `ebnf:"@0"`
+		Count uint `ebnf:"@0"`
+	}); n.Value != 10 || n.Count != 10 {
+		t.Errorf("expected 10, got %+v", n)
+	}
+}
+
+func TestBindInnerRule(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		assignment = identifier, ":=", value ;
+		value = number | identifier ;
+		identifier = "x" | "y" ;
+		number = "1" | "2" ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	binding := &struct {
+		Number int    `ebnf:"number"`
+		Value  string `ebnf:"@2"`
+	}{}
+
+	if err = grammar.Bind("assignment", binding); err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	reader, _ := NewReader(strings.NewReader("x:=2"))
+
+	result, err := grammar.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	// The number result is found inside the value result
+	bound, ok := result.Result.(*struct {
+		Number int    `ebnf:"number"`
+		Value  string `ebnf:"@2"`
+	})
+	if !ok || bound.Number != 2 || bound.Value != "2" {
+		t.Errorf("unexpected binding %+v", result.Result)
+	}
+}
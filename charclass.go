@@ -0,0 +1,218 @@
+package ebnf
+
+import (
+	"fmt"
+	"sort"
+	"unicode"
+)
+
+// RuneRange holds an inclusive range of runes
+type RuneRange struct {
+	Lo rune
+	Hi rune
+}
+
+// CharacterClass is an immutable set of runes built from ranges, enums, Unicode tables and set
+// operations. The set is held as sorted non overlapping ranges so it can be inspected by tools
+type CharacterClass struct {
+	ranges []RuneRange
+	name   string
+	// composite is true if the name is a set operation which needs parentheses as an operand
+	composite bool
+}
+
+// newCharacterClass creates a class from ranges in any order
+func newCharacterClass(ranges []RuneRange, name string, composite bool) *CharacterClass {
+	sorted := append([]RuneRange{}, ranges...)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Lo < sorted[j].Lo
+	})
+
+	merged := []RuneRange{}
+
+	for _, rr := range sorted {
+		if rr.Lo > rr.Hi {
+			continue
+		}
+
+		if l := len(merged) - 1; l >= 0 && rr.Lo <= merged[l].Hi+1 {
+			if rr.Hi > merged[l].Hi {
+				merged[l].Hi = rr.Hi
+			}
+
+			continue
+		}
+
+		merged = append(merged, rr)
+	}
+
+	return &CharacterClass{
+		ranges:    merged,
+		name:      name,
+		composite: composite,
+	}
+}
+
+// NewClassRange creates a class of the runes from low to high
+func NewClassRange(low rune, high rune) *CharacterClass {
+	return newCharacterClass([]RuneRange{{Lo: low, Hi: high}}, fmt.Sprintf("%q..%q", low, high), false)
+}
+
+// NewClassEnum creates a class of the runes in a string
+func NewClassEnum(enum string) *CharacterClass {
+	ranges := []RuneRange{}
+
+	for _, rn := range enum {
+		ranges = append(ranges, RuneRange{Lo: rn, Hi: rn})
+	}
+
+	return newCharacterClass(ranges, fmt.Sprintf("one of %q", enum), false)
+}
+
+// NewClassTable creates a class of the runes in a Unicode range table, name describes the table
+func NewClassTable(name string, table *unicode.RangeTable) *CharacterClass {
+	ranges := []RuneRange{}
+
+	for _, r16 := range table.R16 {
+		ranges = appendStrideRanges(ranges, rune(r16.Lo), rune(r16.Hi), rune(r16.Stride))
+	}
+
+	for _, r32 := range table.R32 {
+		ranges = appendStrideRanges(ranges, rune(r32.Lo), rune(r32.Hi), rune(r32.Stride))
+	}
+
+	return newCharacterClass(ranges, name, false)
+}
+
+// appendStrideRanges appends the runes from lo to hi with a stride
+func appendStrideRanges(ranges []RuneRange, lo rune, hi rune, stride rune) []RuneRange {
+	if stride == 1 {
+		return append(ranges, RuneRange{Lo: lo, Hi: hi})
+	}
+
+	for rn := lo; rn <= hi; rn += stride {
+		ranges = append(ranges, RuneRange{Lo: rn, Hi: rn})
+	}
+
+	return ranges
+}
+
+// NewUnicodeClass creates a class for a Unicode category (for instance "L" or "Lm"), script (for
+// instance "Greek") or property (for instance "White_Space") as named by package unicode
+func NewUnicodeClass(name string) (*CharacterClass, error) {
+	for _, tables := range []map[string]*unicode.RangeTable{unicode.Categories, unicode.Scripts, unicode.Properties} {
+		if table, ok := tables[name]; ok {
+			return NewClassTable(fmt.Sprintf(`\p{%s}`, name), table), nil
+		}
+	}
+
+	return nil, fmt.Errorf("unknown unicode class %q", name)
+}
+
+// operand returns the name of the class as operand of a set operation
+func (c *CharacterClass) operand() string {
+	if c.composite {
+		return "(" + c.name + ")"
+	}
+
+	return c.name
+}
+
+// Union returns the class of the runes in this class or in one of the other classes
+func (c *CharacterClass) Union(others ...*CharacterClass) *CharacterClass {
+	ranges := append([]RuneRange{}, c.ranges...)
+	name := c.operand()
+
+	for _, other := range others {
+		ranges = append(ranges, other.ranges...)
+		name += " | " + other.operand()
+	}
+
+	return newCharacterClass(ranges, name, len(others) > 0 || c.composite)
+}
+
+// Negate returns the class of the runes that are not in this class
+func (c *CharacterClass) Negate() *CharacterClass {
+	ranges := []RuneRange{}
+	next := rune(0)
+
+	for _, rr := range c.ranges {
+		if rr.Lo > next {
+			ranges = append(ranges, RuneRange{Lo: next, Hi: rr.Lo - 1})
+		}
+
+		next = rr.Hi + 1
+	}
+
+	if next <= unicode.MaxRune {
+		ranges = append(ranges, RuneRange{Lo: next, Hi: unicode.MaxRune})
+	}
+
+	return newCharacterClass(ranges, "not "+c.operand(), false)
+}
+
+// Intersect returns the class of the runes in both classes
+func (c *CharacterClass) Intersect(other *CharacterClass) *CharacterClass {
+	ranges := []RuneRange{}
+
+	for i, j := 0, 0; i < len(c.ranges) && j < len(other.ranges); {
+		a, b := c.ranges[i], other.ranges[j]
+
+		lo, hi := a.Lo, a.Hi
+		if b.Lo > lo {
+			lo = b.Lo
+		}
+
+		if b.Hi < hi {
+			hi = b.Hi
+		}
+
+		if lo <= hi {
+			ranges = append(ranges, RuneRange{Lo: lo, Hi: hi})
+		}
+
+		if a.Hi < b.Hi {
+			i++
+		} else {
+			j++
+		}
+	}
+
+	return newCharacterClass(ranges, c.operand()+" & "+other.operand(), true)
+}
+
+// Difference returns the class of the runes in this class but not in the other class
+func (c *CharacterClass) Difference(other *CharacterClass) *CharacterClass {
+	difference := c.Intersect(other.Negate())
+	difference.name = c.operand() + " - " + other.operand()
+
+	return difference
+}
+
+// Contains returns true if a rune is in the class
+func (c *CharacterClass) Contains(rn rune) bool {
+	i := sort.Search(len(c.ranges), func(i int) bool {
+		return c.ranges[i].Hi >= rn
+	})
+
+	return i < len(c.ranges) && c.ranges[i].Lo <= rn
+}
+
+// Ranges returns the sorted non overlapping ranges of the class
+func (c *CharacterClass) Ranges() []RuneRange {
+	return append([]RuneRange{}, c.ranges...)
+}
+
+// String returns the description of the class, for instance \p{L} - \p{Lm}
+func (c *CharacterClass) String() string {
+	return c.name
+}
+
+// NewCharacterClassGroup creates a new character group matching the runes of a class
+func NewCharacterClassGroup(class *CharacterClass, reversed bool, t TransformFunction) *CharacterGroup {
+	g := NewCharacterGroup(class.Contains, reversed, t)
+	g.Class = class
+	g.Name = characterGroupName(class.String(), reversed)
+	return g
+}
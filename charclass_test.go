@@ -0,0 +1,93 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestCharacterClass(t *testing.T) {
+	letters, err := NewUnicodeClass("L")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	modifiers, _ := NewUnicodeClass("Lm")
+	greek, _ := NewUnicodeClass("Greek")
+
+	class := letters.Difference(modifiers)
+
+	for rn, expected := range map[rune]bool{'a': true, 'λ': true, 'ʰ': false, '1': false} {
+		if class.Contains(rn) != expected {
+			t.Errorf("%q: expected %v", rn, expected)
+		}
+	}
+
+	if class.String() != `\p{L} - \p{Lm}` {
+		t.Errorf("unexpected class name %q", class.String())
+	}
+
+	if _, err = NewUnicodeClass("Klingon"); err == nil {
+		t.Errorf("expected error for unknown class")
+	}
+
+	// Set operations on ranges
+	digits := NewClassRange('0', '9')
+	hex := digits.Union(NewClassRange('a', 'f'), NewClassEnum("ABCDEF"))
+
+	if ranges := hex.Ranges(); len(ranges) != 3 || ranges[1] != (RuneRange{Lo: 'A', Hi: 'F'}) {
+		t.Errorf("unexpected ranges %v", ranges)
+	}
+
+	notHex := hex.Negate()
+	if notHex.Contains('b') || !notHex.Contains('g') || !notHex.Contains(unicode.MaxRune) || !notHex.Contains(0) {
+		t.Errorf("unexpected negated class %v", notHex.Ranges())
+	}
+
+	greekLetters := greek.Intersect(letters.Difference(NewClassRange('α', 'ω')))
+	if greekLetters.Contains('λ') || !greekLetters.Contains('Λ') || greekLetters.Contains('L') {
+		t.Errorf("unexpected intersection")
+	}
+
+	if name := greekLetters.String(); name != `\p{Greek} & (\p{L} - 'α'..'ω')` {
+		t.Errorf("unexpected class name %q", name)
+	}
+
+	if name := hex.Negate().String(); name != `not ('0'..'9' | 'a'..'f' | one of "ABCDEF")` {
+		t.Errorf("unexpected class name %q", name)
+	}
+}
+
+func TestCharacterClassGroup(t *testing.T) {
+	letters, _ := NewUnicodeClass("L")
+	identifier := NewRepetition(NewCharacterClassGroup(letters.Union(NewClassEnum("_")), false, nil), 1, 0, nil)
+
+	reader, _ := NewReader(strings.NewReader("größe_1"))
+
+	result, err := identifier.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || reader.StringFromResult(result) != "größe_" {
+		t.Errorf("expected identifier to match")
+	}
+
+	reader, _ = NewReader(strings.NewReader("1"))
+
+	_, err = Parse(NewCharacterClassGroup(NewClassRange('0', '9'), true, nil), reader)
+	if err == nil || err.Error() != `expected not '0'..'9' at line 1, col 1, found '1'` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	// Classes are used to find shadowed alternatives
+	grammar := NewGrammar().Rule("token", NewAlternation([]Pattern{
+		NewCharacterClassGroup(letters, false, nil),
+		NewCharacterRange('a', 'z', false, nil),
+	}, nil))
+
+	issues := grammar.Analyze().Issues
+	if len(issues) != 1 || issues[0].String() != `rule "token": alternative 2 is shadowed by alternative 1` {
+		t.Errorf("unexpected issues %v", issues)
+	}
+}
@@ -0,0 +1,440 @@
+package ebnf
+
+import (
+	"strconv"
+)
+
+// firstFunc returns true if a match of a pattern can start with a rune
+type firstFunc func(rn rune) bool
+
+// dispatchTable holds for each alternative of an alternation a function which tells if the
+// alternative can start with a rune, and the expectations recorded when the alternative fails at
+// its begin position. The alternatives that can start with an ASCII rune are precomputed
+type dispatchTable struct {
+	patterns []Pattern
+	grammar  *Grammar
+	unbound  bool
+	grammars map[*Grammar]int
+	states   []patternState
+	firsts   []firstFunc
+	expected [][]string
+	ascii    [128][]int
+}
+
+// patternState holds the exported fields of a pattern a dispatch table is derived from, patterns can
+// be modified after they are constructed (for instance to build recursive patterns). Function fields
+// other than transforms can not be compared and are assumed not to change
+type patternState struct {
+	pattern   Pattern
+	children  []Pattern
+	text      string
+	min       int
+	max       int
+	flags     int
+	grammar   *Grammar
+	transform bool
+}
+
+// newPatternState returns the current state of a pattern, the children are shared with the pattern
+func newPatternState(p Pattern) patternState {
+	s := patternState{
+		pattern:  p,
+		children: patternChildren(p),
+	}
+
+	if t, ok := p.(interface{ transform() TransformFunction }); ok {
+		s.transform = t.transform() != nil
+	}
+
+	switch p := p.(type) {
+	case *TerminalString:
+		s.text = p.String
+		s.min = int(p.Options.Folding)
+
+		if p.Options.Normalize != nil {
+			s.flags = 1
+		}
+	case *CharacterGroup:
+		if p.Reversed {
+			s.flags = 1
+		}
+	case *Repetition:
+		s.min, s.max = p.Min, p.Max
+	case *Regexp:
+		s.text = p.Regexp.String()
+	case *Reference:
+		s.text = p.Name
+		s.grammar = p.Grammar
+	}
+
+	return s
+}
+
+// equal returns true if the pattern is still in the state
+func (s *patternState) equal(other *patternState) bool {
+	if s.text != other.text || s.min != other.min || s.max != other.max || s.flags != other.flags ||
+		s.grammar != other.grammar || s.transform != other.transform || len(s.children) != len(other.children) {
+		return false
+	}
+
+	for i, child := range s.children {
+		if child != other.children[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// dispatchBuilder derives first functions and expectations from patterns, unbound references are
+// resolved in the grammar that is being matched. The versions of the grammars and the states of the
+// patterns are recorded
+type dispatchBuilder struct {
+	unbound  bool
+	grammars map[*Grammar]int
+	recorded map[Pattern]bool
+	states   []patternState
+	visiting map[Pattern]bool
+}
+
+// record records the state of a pattern
+func (b *dispatchBuilder) record(p Pattern) {
+	if !b.recorded[p] {
+		s := newPatternState(p)
+		s.children = append([]Pattern{}, s.children...)
+
+		b.recorded[p] = true
+		b.states = append(b.states, s)
+	}
+}
+
+// resolve returns the grammar and pattern of a referenced rule
+func (b *dispatchBuilder) resolve(g *Grammar, ref *Reference) (*Grammar, Pattern) {
+	if ref.Grammar != nil {
+		g = ref.Grammar
+	} else {
+		b.unbound = true
+	}
+
+	if g == nil {
+		return nil, nil
+	}
+
+	b.grammars[g] = g.version
+
+	return g, g.rules[ref.Name]
+}
+
+// first returns the first function of a pattern and if the pattern can match without consuming
+// input. The first function is nil if the runes the pattern can start with are not known
+func (b *dispatchBuilder) first(g *Grammar, p Pattern) (firstFunc, bool) {
+	if p == nil || b.visiting[p] {
+		return nil, false
+	}
+
+	b.visiting[p] = true
+	defer delete(b.visiting, p)
+
+	b.record(p)
+
+	switch p := p.(type) {
+	case *TerminalString:
+		runes := []rune(p.String)
+		if len(runes) == 0 {
+			return nil, true
+		}
+
+		// The first rune of normalized input is not known
+		if p.Options.Normalize != nil {
+			return nil, false
+		}
+
+		first := p.fold(runes[0])
+
+		return func(rn rune) bool {
+			return p.fold(rn) == first
+		}, false
+	case *CharacterGroup:
+		return func(rn rune) bool {
+			return p.Group(rn) != p.Reversed
+		}, false
+	case *LiteralSet:
+		if p.root.terminal {
+			return nil, true
+		}
+
+		return func(rn rune) bool {
+			return p.root.children[p.key(rn)] != nil
+		}, false
+	case *EOF:
+		// Alternatives are only dispatched if there is a next rune
+		return func(rn rune) bool {
+			return false
+		}, false
+	case *Alternation:
+		return b.union(g, p.Patterns, false)
+	case *Concatenation:
+		return b.union(g, p.Patterns, true)
+	case *Repetition:
+		f, nullable := b.first(g, p.Pattern)
+		return f, nullable || p.Min == 0
+	case *Exception:
+		return b.first(g, p.MustMatch)
+	case *And:
+		// A positive lookahead only matches if the pattern can start with the next rune
+		return b.first(g, p.Pattern)
+	case *Trivia:
+		return b.first(g, p.Pattern)
+	case *Binding:
+		return b.first(g, p.Pattern)
+	case *Not:
+		return nil, true
+	case *Regexp:
+		return nil, p.Regexp.MatchString("")
+	case *Reference:
+		g, rule := b.resolve(g, p)
+		return b.first(g, rule)
+	}
+
+	return nil, false
+}
+
+// union returns the first function of alternatives or of a sequence of patterns. A sequence can start
+// with a rune if one of its patterns up to and including the first pattern which consumes input can
+// start with the rune
+func (b *dispatchBuilder) union(g *Grammar, patterns []Pattern, sequence bool) (firstFunc, bool) {
+	firsts := []firstFunc{}
+	nullable := sequence
+
+	for _, p := range patterns {
+		f, pNullable := b.first(g, p)
+		if f == nil && !pNullable {
+			return nil, false
+		}
+
+		if f != nil {
+			firsts = append(firsts, f)
+		}
+
+		if sequence && !pNullable {
+			nullable = false
+			break
+		}
+
+		if !sequence && pNullable {
+			nullable = true
+		}
+	}
+
+	return func(rn rune) bool {
+		for _, f := range firsts {
+			if f(rn) {
+				return true
+			}
+		}
+
+		return false
+	}, nullable
+}
+
+// skippable returns true if skipping a pattern that fails at its begin position has the same effect
+// as trying it. Trying the pattern calls the transforms of the patterns it tries before failing, and
+// a concatenation of which the first pattern matches empty input fails with a partial match
+func (b *dispatchBuilder) skippable(g *Grammar, p Pattern, visited map[Pattern]bool) bool {
+	// Recursion through a rule is left recursion, which is not skipped
+	if p == nil || visited[p] {
+		return false
+	}
+
+	visited[p] = true
+	defer delete(visited, p)
+
+	b.record(p)
+
+	if t, ok := p.(interface{ transform() TransformFunction }); ok && t.transform() != nil {
+		return false
+	}
+
+	switch p := p.(type) {
+	case *TerminalString, *CharacterGroup, *LiteralSet, *Regexp, *EOF:
+		return true
+	case *Alternation:
+		for _, child := range p.Patterns {
+			if !b.skippable(g, child, visited) {
+				return false
+			}
+		}
+
+		return true
+	case *Concatenation:
+		if len(p.Patterns) == 0 {
+			return false
+		}
+
+		if _, nullable := b.first(g, p.Patterns[0]); nullable {
+			return false
+		}
+
+		return b.skippable(g, p.Patterns[0], visited)
+	case *Repetition:
+		return p.Min > 0 && b.skippable(g, p.Pattern, visited)
+	case *Exception:
+		// The exception is not tried if the pattern fails
+		return b.skippable(g, p.MustMatch, visited)
+	case *And:
+		return b.skippable(g, p.Pattern, visited)
+	case *Trivia:
+		return b.skippable(g, p.Pattern, visited)
+	case *Binding:
+		return b.skippable(g, p.Pattern, visited)
+	case *Reference:
+		g, rule := b.resolve(g, p)
+		return b.skippable(g, rule, visited)
+	}
+
+	return false
+}
+
+// expected returns the expectations recorded by a pattern that fails at its begin position
+func (b *dispatchBuilder) expected(g *Grammar, p Pattern) []string {
+	if p == nil || b.visiting[p] {
+		return nil
+	}
+
+	b.visiting[p] = true
+	defer delete(b.visiting, p)
+
+	b.record(p)
+
+	switch p := p.(type) {
+	case *TerminalString:
+		return []string{strconv.Quote(p.String)}
+	case *CharacterGroup:
+		return []string{p.Name}
+	case *LiteralSet:
+		expected := []string{}
+
+		for _, literal := range p.Literals {
+			expected = append(expected, strconv.Quote(literal))
+		}
+
+		return expected
+	case *EOF:
+		return []string{"end of input"}
+	case *Alternation:
+		expected := []string{}
+
+		for _, child := range p.Patterns {
+			expected = append(expected, b.expected(g, child)...)
+		}
+
+		return expected
+	case *Concatenation:
+		expected := []string{}
+
+		for _, child := range p.Patterns {
+			expected = append(expected, b.expected(g, child)...)
+
+			if _, nullable := b.first(g, child); !nullable {
+				break
+			}
+		}
+
+		return expected
+	case *Repetition:
+		return b.expected(g, p.Pattern)
+	case *Exception:
+		return b.expected(g, p.MustMatch)
+	case *And:
+		return b.expected(g, p.Pattern)
+	case *Trivia:
+		return b.expected(g, p.Pattern)
+	case *Binding:
+		return b.expected(g, p.Pattern)
+	case *Reference:
+		return []string{p.Name}
+	}
+
+	return nil
+}
+
+// newDispatchTable builds the dispatch table of an alternation for grammar g
+func newDispatchTable(patterns []Pattern, g *Grammar) *dispatchTable {
+	b := &dispatchBuilder{
+		grammars: map[*Grammar]int{},
+		recorded: map[Pattern]bool{},
+		visiting: map[Pattern]bool{},
+	}
+
+	t := &dispatchTable{
+		patterns: append([]Pattern{}, patterns...),
+		grammar:  g,
+		grammars: b.grammars,
+		firsts:   make([]firstFunc, len(patterns)),
+		expected: make([][]string, len(patterns)),
+	}
+
+	for i, p := range patterns {
+		// Alternatives which can match without consuming input or which can not be skipped are always tried
+		f, nullable := b.first(g, p)
+		if f != nil && !nullable && b.skippable(g, p, map[Pattern]bool{}) {
+			t.firsts[i] = f
+			t.expected[i] = b.expected(g, p)
+		}
+	}
+
+	t.unbound = b.unbound
+	t.states = b.states
+
+	for rn := range t.ascii {
+		t.ascii[rn] = t.candidates(rune(rn))
+	}
+
+	return t
+}
+
+// candidates returns the indices of the alternatives that can start with a rune
+func (t *dispatchTable) candidates(rn rune) []int {
+	indices := []int{}
+
+	for i, f := range t.firsts {
+		if f == nil || f(rn) {
+			indices = append(indices, i)
+		}
+	}
+
+	return indices
+}
+
+// valid returns true if the table was built for the patterns, the current rules of the grammars and the
+// current state of the patterns it was derived from
+func (t *dispatchTable) valid(patterns []Pattern, g *Grammar) bool {
+	if len(patterns) != len(t.patterns) {
+		return false
+	}
+
+	for i, p := range patterns {
+		if p != t.patterns[i] {
+			return false
+		}
+	}
+
+	// Unbound references are resolved in the grammar that is being matched
+	if t.unbound && t.grammar != g {
+		return false
+	}
+
+	for grammar, version := range t.grammars {
+		if grammar.version != version {
+			return false
+		}
+	}
+
+	for i := range t.states {
+		if current := newPatternState(t.states[i].pattern); !t.states[i].equal(&current) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,235 @@
+package ebnf
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestAlternationDispatch(t *testing.T) {
+	// Alternatives are wrapped in concatenations, every tried alternative is a memo miss
+	keywords := []Pattern{}
+	for _, keyword := range []string{"begin", "end", "if", "then", "else", "while", "do", "return"} {
+		keywords = append(keywords, NewConcatenation([]Pattern{NewTerminalString(keyword, nil)}, nil))
+	}
+
+	keywords = append(keywords, NewConcatenation([]Pattern{NewOptional(NewTerminalString("-", nil), nil), NewCharacterRange('0', '9', false, nil)}, nil))
+	alternation := NewAlternation(keywords, nil)
+
+	match := func(input string) (*MatchResult, int) {
+		reader, _ := NewReader(strings.NewReader(input))
+		reader.SetMemoization(true)
+
+		result, err := alternation.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		return result, reader.MemoStats().Misses - 1
+	}
+
+	result, tried := match("while")
+	if !result.Match || result.Result.([]*MatchResult)[0].Result != "while" || tried != 1 {
+		t.Errorf("expected only the while alternative to be tried, tried %d", tried)
+	}
+
+	// Alternatives that can start with the same rune are tried in order
+	result, tried = match("else")
+	if !result.Match || result.Result.([]*MatchResult)[0].Result != "else" || tried != 2 {
+		t.Errorf("expected end and else alternatives to be tried, tried %d", tried)
+	}
+
+	// Skipped alternatives are reported as expected
+	reader, _ := NewReader(strings.NewReader("x"))
+
+	_, err := Parse(alternation, reader)
+	if err == nil || err.Error() != `expected "begin", "end", "if", "then", "else", "while", "do", "return", "-" or '0'..'9' at line 1, col 1, found 'x'` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestAlternationDispatchTransforms(t *testing.T) {
+	failed := 0
+	alternation := NewAlternation([]Pattern{
+		NewTerminalString("a", func(m *MatchResult, r *Reader) error {
+			if !m.Match {
+				failed++
+			}
+			return nil
+		}),
+		NewTerminalString("b", nil),
+	}, nil)
+
+	reader, _ := NewReader(strings.NewReader("b"))
+
+	result, err := alternation.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	// Alternatives with transforms are not skipped
+	if !result.Match || failed != 1 {
+		t.Errorf("expected the transform of the failed alternative to be called, called %d times", failed)
+	}
+}
+
+func TestAlternationDispatchPartialMatch(t *testing.T) {
+	partial := NewConcatenation([]Pattern{NewOptional(NewTerminalString("a", nil), nil), NewTerminalString("b", nil)}, nil)
+	alternation := NewAlternation([]Pattern{partial, NewTerminalString("d", nil)}, nil)
+
+	reader, _ := NewReader(strings.NewReader("c"))
+
+	result, err := alternation.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	// The concatenation is tried because its first pattern matches empty input
+	if result.Match || result.Failed == nil || !result.Failed.PartialMatch {
+		t.Errorf("expected the partial match of the concatenation")
+	}
+}
+
+// dispatchJSONGrammar returns a JSON grammar without transforms
+func dispatchJSONGrammar(tb testing.TB) *Grammar {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		json = ws, value, ws ;
+		value = object | array | string | "true" | "false" | "null" | number ;
+		object = "{", ws, [ member, { ws, ",", ws, member } ], ws, "}" ;
+		member = string, ws, ":", ws, value ;
+		array = "[", ws, [ value, { ws, ",", ws, value } ], ws, "]" ;
+		string = '"', { escape | ? character ? }, '"' ;
+		escape = "\", ? escaped ? ;
+		number = [ "-" ], digit, { digit }, [ ".", digit, { digit } ] ;
+		digit = "0" | "1" | "2" | "3" | "4" | "5" | "6" | "7" | "8" | "9" ;
+		ws = { ? white space ? } ;
+	`))
+	if err != nil {
+		tb.Fatalf("err %v", err)
+	}
+
+	grammar.
+		Rule("character", NewCharacterGroup(func(rn rune) bool {
+			return unicode.IsControl(rn) || rn == '"' || rn == '\\'
+		}, true, nil)).
+		Rule("escaped", NewCharacterEnum(`"\/bfnrt`, false, nil)).
+		Rule("white space", NewCharacterEnum(" \n\r\t", false, nil))
+
+	return grammar
+}
+
+func TestAlternationDispatchJSON(t *testing.T) {
+	input, err := ioutil.ReadFile("test.json")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	grammar := dispatchJSONGrammar(t)
+	tried := map[bool]int{}
+
+	for _, ordered := range []bool{true, false} {
+		reader := NewBytesReader(input)
+		reader.SetMemoization(true)
+		reader.ordered = ordered
+
+		result, err := Parse(grammar, reader)
+		if err != nil || !result.Match {
+			t.Fatalf("expected json to match, err %v", err)
+		}
+
+		tried[ordered] = reader.MemoStats().Misses
+	}
+
+	// Dispatch skips most alternatives of values and string characters
+	if tried[false]*4 > tried[true]*3 {
+		t.Errorf("expected dispatch to try a quarter fewer patterns, %d with dispatch and %d without", tried[false], tried[true])
+	}
+}
+
+func BenchmarkAlternationDispatchJSON(b *testing.B) {
+	input, err := ioutil.ReadFile("test.json")
+	if err != nil {
+		b.Fatalf("err %v", err)
+	}
+
+	grammar := dispatchJSONGrammar(b)
+
+	for _, ordered := range []bool{true, false} {
+		name := "dispatch"
+		if ordered {
+			name = "ordered"
+		}
+
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				reader := NewBytesReader(input)
+				reader.ordered = ordered
+
+				if result, err := Parse(grammar, reader); err != nil || !result.Match {
+					b.Fatalf("expected json to match, err %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestAlternationDispatchGrammar(t *testing.T) {
+	grammar := NewGrammar().
+		Rule("value", NewAlternation([]Pattern{Ref("a"), Ref("b")}, nil)).
+		Rule("a", NewTerminalString("a", nil)).
+		Rule("b", NewTerminalString("b", nil))
+
+	match := func(input string) bool {
+		reader, _ := NewReader(strings.NewReader(input))
+
+		result, err := grammar.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		return result.Match
+	}
+
+	if !match("a") || !match("b") || match("c") {
+		t.Fatalf("unexpected match of value")
+	}
+
+	// The dispatch table is rebuilt when a rule changes
+	grammar.Rule("b", NewTerminalString("c", nil))
+
+	if !match("c") || match("b") {
+		t.Errorf("expected dispatch table to be rebuilt")
+	}
+}
+
+func TestAlternationDispatchModified(t *testing.T) {
+	inner := NewConcatenation([]Pattern{NewTerminalString("a", nil)}, nil)
+	keyword := NewTerminalString("if", nil)
+	repetition := NewRepetition(NewTerminalString("x", nil), 1, 0, nil)
+	alternation := NewAlternation([]Pattern{inner, keyword, NewConcatenation([]Pattern{repetition, NewTerminalString("y", nil)}, nil)}, nil)
+
+	match := func(input string) bool {
+		reader, _ := NewReader(strings.NewReader(input))
+
+		result, err := alternation.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		return result.Match && reader.Finished()
+	}
+
+	if !match("a") || !match("if") || !match("xy") || match("b") || match("y") {
+		t.Fatalf("unexpected match of alternation")
+	}
+
+	// The dispatch table is rebuilt when nested patterns are modified after the first match
+	inner.Patterns = []Pattern{NewTerminalString("b", nil)}
+	keyword.String = "do"
+	repetition.Min = 0
+
+	if !match("b") || !match("do") || !match("y") || match("a") || match("if") {
+		t.Errorf("expected dispatch table to be rebuilt")
+	}
+}
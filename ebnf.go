@@ -12,18 +12,29 @@ package ebnf
 import (
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"unicode/utf8"
 )
 
-// MatchResult contains the result of a match
+// MatchResult contains the result of a match, Rule holds the name of the grammar rule
+// that produced the result (if any), Recovered is set for error nodes (see Recover) and Node
+// holds the syntax tree node if the syntax tree is enabled (see Reader.SetSyntaxTree). Inner holds
+// the result of another rule if the result of that rule is the result of the rule, Result is shared
+// with the inner result
 type MatchResult struct {
+	Rule         string
+	Inner        *MatchResult
 	Match        bool
 	PartialMatch bool
+	Recovered    bool
 	BeginPos     *ReaderPos
 	EndPos       *ReaderPos
 	Result       interface{}
 	Error        error
 	Failed       *MatchResult
+	Node         *Node
 }
 
 // RangeString returns the range of the match as a string
@@ -53,19 +64,51 @@ type BaseTransformer struct {
 	T TransformFunction
 }
 
-// Transform for base transformer
+// transform returns the transform function of the base transformer
+func (b *BaseTransformer) transform() TransformFunction {
+	return b.T
+}
+
+// Transform for base transformer, returns the reader error if input was requested that is not available
 func (b *BaseTransformer) Transform(m *MatchResult, r *Reader) error {
 	if b.T != nil {
-		return b.T(m, r)
+		err := b.T(m, r)
+		if err != nil {
+			return err
+		}
 	}
 
-	return nil
+	return r.err
+}
+
+// CaseFolding determines how the case of runes is compared
+type CaseFolding int
+
+const (
+	// NoCaseFolding compares runes exactly
+	NoCaseFolding CaseFolding = iota
+	// ASCIICaseFolding ignores the case of the ASCII letters a-z
+	ASCIICaseFolding
+	// UnicodeCaseFolding ignores case using Unicode simple case folding
+	UnicodeCaseFolding
+)
+
+// TerminalOptions holds the options of a terminal string
+type TerminalOptions struct {
+	Folding CaseFolding
+	// Normalize is applied to the terminal string and to the input before they are compared, for
+	// instance norm.NFC.String or norm.NFKC.String of package golang.org/x/text/unicode/norm. The
+	// normalization forms are not built in, so this package keeps depending on the standard library only
+	Normalize func(s string) string
+	// Literal sets MatchResult.Result to the terminal string instead of the matched input
+	Literal bool
 }
 
 // TerminalString pattern
 type TerminalString struct {
 	BaseTransformer
-	String string
+	String  string
+	Options TerminalOptions
 }
 
 // NewTerminalString creates a new terminal string
@@ -78,53 +121,147 @@ func NewTerminalString(s string, t TransformFunction) *TerminalString {
 	}
 }
 
-// Match a terminal string, MatchResult.Result will contain a string
-func (s *TerminalString) Match(r *Reader) (*MatchResult, error) {
-	beginPos := r.CurrentPosition()
+// NewTerminalStringWithOptions creates a new terminal string with options for case folding and
+// normalization
+func NewTerminalStringWithOptions(s string, opts TerminalOptions, t TransformFunction) *TerminalString {
+	return &TerminalString{
+		BaseTransformer: BaseTransformer{
+			T: t,
+		},
+		String:  s,
+		Options: opts,
+	}
+}
 
-	r.PushState()
+// fold returns the rune to compare for rn
+func (s *TerminalString) fold(rn rune) rune {
+	switch s.Options.Folding {
+	case ASCIICaseFolding:
+		if rn >= 'A' && rn <= 'Z' {
+			return rn + 'a' - 'A'
+		}
+	case UnicodeCaseFolding:
+		return foldRune(rn)
+	}
 
-	result := &MatchResult{Match: false}
-	result.BeginPos = beginPos
+	return rn
+}
 
-	for _, rn1 := range []rune(s.String) {
+// foldString returns the string to compare for str
+func (s *TerminalString) foldString(str string) string {
+	if s.Options.Folding == NoCaseFolding {
+		return str
+	}
+
+	return strings.Map(s.fold, str)
+}
+
+// read reads the input that matches the terminal string, returns false if the input does not match
+func (s *TerminalString) read(r *Reader) (bool, error) {
+	if s.Options.Normalize != nil {
+		return s.readNormalized(r)
+	}
+
+	for _, rn1 := range s.String {
 		rn2, err := r.Read()
+		if err == io.EOF {
+			return false, nil
+		}
+
 		if err != nil {
-			if err == io.EOF {
-				result.EndPos = r.CurrentPosition()
+			return false, err
+		}
 
-				err = s.Transform(result, r)
-				if err != nil {
-					return nil, err
-				}
+		if s.fold(rn1) != s.fold(rn2) {
+			return false, nil
+		}
+	}
 
-				r.RestoreState()
+	return true, nil
+}
 
-				return result, nil
-			}
+// readNormalized reads the shortest input that is equal to the terminal string after normalization,
+// input of which the last rune combines with the next rune does not match
+func (s *TerminalString) readNormalized(r *Reader) (bool, error) {
+	normalize := s.Options.Normalize
+	target := s.foldString(normalize(s.String))
 
-			return nil, err
-		}
+	// The normalized form of the input can have less or more runes than the input
+	maxRunes := 4*utf8.RuneCountInString(target) + 4
+	input := []rune{}
 
-		if rn1 != rn2 {
-			result.EndPos = r.CurrentPosition()
+	for len(input) < maxRunes {
+		if s.foldString(normalize(string(input))) == target {
+			next, err := r.Peak()
+			if err == io.EOF {
+				return true, nil
+			}
 
-			err = s.Transform(result, r)
 			if err != nil {
-				return nil, err
+				return false, err
 			}
 
-			r.RestoreState()
+			if strings.HasPrefix(s.foldString(normalize(string(append(input, next)))), target) {
+				return true, nil
+			}
+		}
 
-			return result, nil
+		rn, err := r.Read()
+		if err == io.EOF {
+			return false, nil
 		}
+
+		if err != nil {
+			return false, err
+		}
+
+		input = append(input, rn)
+	}
+
+	return false, nil
+}
+
+// Match a terminal string, MatchResult.Result will contain a string
+func (s *TerminalString) Match(r *Reader) (*MatchResult, error) {
+	beginPos := r.CurrentPosition()
+
+	r.PushState()
+
+	result := &MatchResult{Match: false}
+	result.BeginPos = beginPos
+
+	match, err := s.read(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !match {
+		r.expect(beginPos, strconv.Quote(s.String))
+
+		result.EndPos = r.CurrentPosition()
+
+		err = s.Transform(result, r)
+		if err != nil {
+			return nil, err
+		}
+
+		r.RestoreState()
+
+		return result, nil
 	}
 
 	result.Match = true
 	result.EndPos = r.CurrentPosition()
-	result.Result = r.String()
 
-	err := s.Transform(result, r)
+	if s.Options.Literal {
+		result.Result = s.String
+	} else {
+		result.Result = r.String()
+	}
+
+	r.setNode(TerminalNode, result, nil)
+
+	err = s.Transform(result, r)
 	if err != nil {
 		return nil, err
 	}
@@ -153,11 +290,15 @@ func NewCharacterGroupEnumFunction(enum string) CharacterGroupFunction {
 	}
 }
 
-// CharacterGroup pattern, test membership of a group, for instance whitespace group
+// CharacterGroup pattern, test membership of a group, for instance whitespace group. Name describes
+// the group in parse errors, a group without a name is not reported as expected. Class holds the
+// runes of the group (before it is reversed) if the group is declared as a character class
 type CharacterGroup struct {
 	BaseTransformer
 	Group    CharacterGroupFunction
 	Reversed bool
+	Name     string
+	Class    *CharacterClass
 }
 
 // NewCharacterGroup creates a new character group
@@ -173,12 +314,27 @@ func NewCharacterGroup(f CharacterGroupFunction, reversed bool, t TransformFunct
 
 // NewCharacterEnum creates a new character enum group
 func NewCharacterEnum(enum string, reversed bool, t TransformFunction) *CharacterGroup {
-	return NewCharacterGroup(NewCharacterGroupEnumFunction(enum), reversed, t)
+	g := NewCharacterGroup(NewCharacterGroupEnumFunction(enum), reversed, t)
+	g.Class = NewClassEnum(enum)
+	g.Name = characterGroupName(g.Class.String(), reversed)
+	return g
 }
 
 // NewCharacterRange creates a new character range group
 func NewCharacterRange(low rune, high rune, reversed bool, t TransformFunction) *CharacterGroup {
-	return NewCharacterGroup(NewCharacterGroupRangeFunction(low, high), reversed, t)
+	g := NewCharacterGroup(NewCharacterGroupRangeFunction(low, high), reversed, t)
+	g.Class = NewClassRange(low, high)
+	g.Name = characterGroupName(g.Class.String(), reversed)
+	return g
+}
+
+// characterGroupName returns the name of a character group for parse errors
+func characterGroupName(name string, reversed bool) string {
+	if reversed {
+		return "not " + name
+	}
+
+	return name
 }
 
 // Match a character from a group
@@ -191,6 +347,8 @@ func (g *CharacterGroup) Match(r *Reader) (*MatchResult, error) {
 
 	rn, err := r.Read()
 	if err == io.EOF {
+		r.expect(beginPos, g.Name)
+
 		result.EndPos = r.CurrentPosition()
 
 		err = g.Transform(result, r)
@@ -217,6 +375,8 @@ func (g *CharacterGroup) Match(r *Reader) (*MatchResult, error) {
 		result.Result = r.String()
 		result.EndPos = r.CurrentPosition()
 
+		r.setNode(CharacterNode, result, nil)
+
 		err = g.Transform(result, r)
 		if err != nil {
 			return nil, err
@@ -224,6 +384,7 @@ func (g *CharacterGroup) Match(r *Reader) (*MatchResult, error) {
 
 		r.PopState()
 	} else {
+		r.expect(beginPos, g.Name)
 		r.RestoreState()
 	}
 
@@ -234,6 +395,7 @@ func (g *CharacterGroup) Match(r *Reader) (*MatchResult, error) {
 type Alternation struct {
 	BaseTransformer
 	Patterns []Pattern
+	dispatch atomic.Value
 }
 
 // NewAlternation creates a new alternation pattern
@@ -246,16 +408,57 @@ func NewAlternation(patterns []Pattern, t TransformFunction) *Alternation {
 	}
 }
 
-// Match alternation pattern, matches if one of the alternating patterns matches, returns the first matching pattern
+// Match alternation pattern, matches if one of the alternating patterns matches, returns the first matching pattern.
+// The alternatives are also tried at the end of input, so alternatives like EOF or optional patterns
+// can match and failing alternatives report what was expected at the end of input
 func (a *Alternation) Match(r *Reader) (*MatchResult, error) {
+	return r.invoke(a, a.match)
+}
+
+// dispatchTable returns the dispatch table of the alternation for the grammar that is being matched,
+// the table is built on first use and rebuilt when the patterns, the patterns they consist of or the
+// rules of the grammar change
+func (a *Alternation) dispatchTable(r *Reader) *dispatchTable {
+	t, ok := a.dispatch.Load().(*dispatchTable)
+	if !ok || !t.valid(a.Patterns, r.grammar) {
+		t = newDispatchTable(a.Patterns, r.grammar)
+		a.dispatch.Store(t)
+	}
+
+	return t
+}
+
+func (a *Alternation) match(r *Reader) (*MatchResult, error) {
 	beginPos := r.CurrentPosition()
 	var partialMatchResult *MatchResult = nil
 
-	for _, p := range a.Patterns {
-		finished := r.Finished()
+	// Only try the alternatives that can start with the next rune, alternatives are always tried if
+	// skipping them could be noticed (see dispatchBuilder.skippable)
+	var t *dispatchTable
+	var candidates []int
 
-		if finished {
-			break
+	if rn, err := r.Peak(); err == nil && !r.ordered {
+		t = a.dispatchTable(r)
+
+		if rn >= 0 && int(rn) < len(t.ascii) {
+			candidates = t.ascii[rn]
+		} else {
+			candidates = t.candidates(rn)
+		}
+	}
+
+	for i, p := range a.Patterns {
+		if t != nil {
+			if len(candidates) == 0 || candidates[0] != i {
+				// Record the expectations of a skipped alternative as if it failed
+				for _, expected := range t.expected[i] {
+					r.expect(beginPos, expected)
+				}
+
+				continue
+			}
+
+			candidates = candidates[1:]
 		}
 
 		result, err := p.Match(r)
@@ -309,6 +512,10 @@ func NewConcatenation(patterns []Pattern, t TransformFunction) *Concatenation {
 // Match concatenation pattern, MatchResult.Result will contain []*MatchResult if matched,
 // otherwise MatchResult.Result will contain the failed match
 func (c *Concatenation) Match(r *Reader) (*MatchResult, error) {
+	return r.invoke(c, c.match)
+}
+
+func (c *Concatenation) match(r *Reader) (*MatchResult, error) {
 	beginPos := r.CurrentPosition()
 	matches := []*MatchResult{}
 	partialMatch := false
@@ -352,6 +559,8 @@ func (c *Concatenation) Match(r *Reader) (*MatchResult, error) {
 		Result:   matches,
 	}
 
+	r.setNode(SequenceNode, result, matches)
+
 	err := c.Transform(result, r)
 	if err != nil {
 		return nil, err
@@ -406,28 +615,50 @@ func NewAny(p Pattern, t TransformFunction) *Repetition {
 	}
 }
 
-// Match repetition pattern, MatchResult.Result will contain []*MatchResult
+// Match repetition pattern, MatchResult.Result will contain []*MatchResult. An iteration that matches
+// without consuming input ends the repetition, as it would match again at the same position. The
+// minimum is considered to be reached by such an iteration and its result is not added
 func (rep *Repetition) Match(r *Reader) (*MatchResult, error) {
+	return r.invoke(rep, rep.match)
+}
+
+func (rep *Repetition) match(r *Reader) (*MatchResult, error) {
 	beginPos := r.CurrentPosition()
 	matches := []*MatchResult{}
 
 	var result *MatchResult
 	var err error
 
+	zeroWidth := false
+
 	r.PushState()
 
 	for {
+		// Once the minimum is reached the repetition can not fail, a streaming reader does not
+		// need to keep the matched input to backtrack
+		if len(matches) >= rep.Min {
+			r.releaseState()
+		}
+
 		finished := r.Finished()
 		if finished {
 			break
 		}
 
+		iterationPos := r.bufPos
+
 		result, err = rep.Pattern.Match(r)
 		if err != nil {
 			return nil, err
 		}
 
 		if result.Match {
+			// The result of an iteration that matches empty input is not added
+			if r.bufPos == iterationPos {
+				zeroWidth = true
+				break
+			}
+
 			matches = append(matches, result)
 
 			if rep.Max != 0 && len(matches) == rep.Max {
@@ -438,7 +669,7 @@ func (rep *Repetition) Match(r *Reader) (*MatchResult, error) {
 		}
 	}
 
-	if len(matches) < rep.Min {
+	if len(matches) < rep.Min && !zeroWidth {
 		failedResult := result
 		if result.Match {
 			failedResult = nil
@@ -469,6 +700,8 @@ func (rep *Repetition) Match(r *Reader) (*MatchResult, error) {
 		Result:   matches,
 	}
 
+	r.setNode(RepetitionNode, result, matches)
+
 	err = rep.Transform(result, r)
 	if err != nil {
 		return nil, err
@@ -499,6 +732,10 @@ func NewException(mustMatch Pattern, except Pattern, t TransformFunction) *Excep
 
 // Match exception pattern, returns the MustMatch match result
 func (e *Exception) Match(r *Reader) (*MatchResult, error) {
+	return r.invoke(e, e.match)
+}
+
+func (e *Exception) match(r *Reader) (*MatchResult, error) {
 	r.PushState()
 
 	result, err := e.Except.Match(r)
@@ -548,10 +785,17 @@ func NewEOF(t TransformFunction) *EOF {
 
 // Match end of file pattern
 func (e *EOF) Match(r *Reader) (result *MatchResult, err error) {
+	pos := r.CurrentPosition()
 	match := r.Finished()
 
+	if !match {
+		r.expect(pos, "end of input")
+	}
+
 	result = &MatchResult{
-		Match: match,
+		Match:    match,
+		BeginPos: pos,
+		EndPos:   pos,
 	}
 
 	err = e.Transform(result, r)
@@ -171,7 +171,7 @@ func TestEBNF(t *testing.T) {
 			),
 			NewTerminalString("END", func(m *MatchResult, r *Reader) error {
 				if !m.Match {
-					m.Error = fmt.Errorf("expected END statement line %d - pos %d", m.BeginPos.linePos+1, m.BeginPos.relativeCharPos+1)
+					m.Error = fmt.Errorf("expected END statement line %d - pos %d", m.BeginPos.Line(), m.BeginPos.Column())
 				}
 				return nil
 			}),
@@ -187,7 +187,7 @@ func TestEBNF(t *testing.T) {
 	if result.Match {
 		program := result.Result.(*program)
 
-		log.Printf("program name %v - start %v, end %v \n", program.Identifier, *result.BeginPos, *result.EndPos)
+		log.Printf("program name %v - start %v, end %v \n", program.Identifier, result.BeginPos, result.EndPos)
 
 		for _, assignment := range program.Assignments {
 			log.Printf("assignment identifier: %v = %v\n", assignment.Identifier, assignment.Value)
@@ -288,3 +288,74 @@ func TestLanguage(t *testing.T) {
 		log.Printf("no match!")
 	}
 }
+
+func TestRepetitionZeroWidth(t *testing.T) {
+	whitespace := NewAny(NewCharacterEnum(" \t", false, nil), nil)
+	pattern := NewConcatenation([]Pattern{
+		NewAny(whitespace, nil),
+		NewRepetition(NewOptional(NewTerminalString("x", nil), nil), 2, 0, nil),
+		NewTerminalString("y", nil),
+	}, nil)
+
+	for input, match := range map[string]bool{"  \ty": true, "xxxy": true, "y": true, "  z": false} {
+		reader, _ := NewReader(strings.NewReader(input))
+
+		result, err := pattern.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		if result.Match != match {
+			t.Errorf("input %q: expected match %v", input, match)
+		}
+	}
+
+	// The empty iteration is not part of the result
+	reader, _ := NewReader(strings.NewReader("ab"))
+
+	result, err := NewAny(NewOptional(NewTerminalString("a", nil), nil), nil).Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if n := len(result.Result.([]*MatchResult)); !result.Match || n != 1 {
+		t.Errorf("expected 1 iteration, got %d", n)
+	}
+}
+
+func TestTerminalStringOptions(t *testing.T) {
+	// Stands in for norm.NFKC.String, which is not a dependency of this package: composes e followed by
+	// a combining acute accent (NFC) and expands the fi ligature (compatibility decomposition of NFKC)
+	normalize := strings.NewReplacer("e\u0301", "\u00e9", "\ufb01", "fi").Replace
+
+	tests := []struct {
+		terminal *TerminalString
+		input    string
+		result   interface{}
+	}{
+		{NewTerminalStringWithOptions("BEGIN", TerminalOptions{Folding: ASCIICaseFolding}, nil), "begin", "begin"},
+		{NewTerminalStringWithOptions("BEGIN", TerminalOptions{Folding: ASCIICaseFolding, Literal: true}, nil), "Begin", "BEGIN"},
+		{NewTerminalStringWithOptions("STRASSE", TerminalOptions{Folding: ASCIICaseFolding}, nil), "straße", nil},
+		{NewTerminalStringWithOptions("ΣΟΦΙΑ", TerminalOptions{Folding: UnicodeCaseFolding}, nil), "σοφια", "σοφια"},
+		{NewTerminalStringWithOptions("caf\u00e9", TerminalOptions{Normalize: normalize}, nil), "cafe\u0301!", "cafe\u0301"},
+		{NewTerminalStringWithOptions("cafe", TerminalOptions{Normalize: normalize}, nil), "cafe\u0301", nil},
+		{NewTerminalStringWithOptions("FIX", TerminalOptions{Normalize: normalize, Folding: UnicodeCaseFolding, Literal: true}, nil), "\ufb01x", "FIX"},
+	}
+
+	for _, test := range tests {
+		reader, _ := NewReader(strings.NewReader(test.input))
+
+		result, err := test.terminal.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		if test.result == nil {
+			if result.Match || reader.CurrentPosition().Offset() != 0 {
+				t.Errorf("%q: expected no match for input %q", test.terminal.String, test.input)
+			}
+		} else if !result.Match || result.Result != test.result {
+			t.Errorf("%q: expected %q for input %q, got %v", test.terminal.String, test.result, test.input, result.Result)
+		}
+	}
+}
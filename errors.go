@@ -0,0 +1,156 @@
+package ebnf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// ParseError describes the furthest position in the input where matching failed and what was
+// expected at that position. Expected holds quoted terminal strings, character group names and
+// names of rules that failed at the error position
+type ParseError struct {
+	Pos      *ReaderPos
+	Found    rune
+	EOF      bool
+	Expected []string
+}
+
+// Error returns the error message, for instance: expected "," or "}" at line 3, col 14, found 'x'
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s at line %d, col %d, %s", e.expectation(), e.Pos.linePos+1, e.Pos.relativeCharPos+1, e.found())
+}
+
+// Message returns the error message without position, for instance: expected "," or "}", found 'x'
+func (e *ParseError) Message() string {
+	return e.expectation() + ", " + e.found()
+}
+
+func (e *ParseError) expectation() string {
+	if len(e.Expected) == 0 {
+		return "unexpected input"
+	}
+
+	var builder strings.Builder
+
+	builder.WriteString("expected ")
+
+	for i, expected := range e.Expected {
+		if i > 0 {
+			if i == len(e.Expected)-1 {
+				builder.WriteString(" or ")
+			} else {
+				builder.WriteString(", ")
+			}
+		}
+
+		builder.WriteString(expected)
+	}
+
+	return builder.String()
+}
+
+func (e *ParseError) found() string {
+	if e.EOF {
+		return "found end of input"
+	}
+
+	return fmt.Sprintf("found %q", e.Found)
+}
+
+// expect records that what was expected at pos but not found, only the expectations at the furthest
+// position are kept. An empty what only records the failure position
+func (r *Reader) expect(pos *ReaderPos, what string) {
+	if r.failPos == nil || pos.absoluteCharPos > r.failPos.absoluteCharPos {
+		r.failPos = pos
+		r.expected = nil
+	} else if pos.absoluteCharPos < r.failPos.absoluteCharPos {
+		return
+	}
+
+	if what == "" {
+		return
+	}
+
+	for _, expected := range r.expected {
+		if expected == what {
+			return
+		}
+	}
+
+	r.expected = append(r.expected, what)
+}
+
+// expectRule replaces the expectations recorded while matching a rule that failed at its begin
+// position by the rule name. n is the number of expectations at pos before the rule was matched
+func (r *Reader) expectRule(pos *ReaderPos, n int, name string) {
+	if r.failPos == nil || r.failPos.absoluteCharPos != pos.absoluteCharPos {
+		return
+	}
+
+	if n < len(r.expected) {
+		r.expected = r.expected[:n]
+	}
+
+	r.expect(pos, name)
+}
+
+// ParseError returns the error at the furthest position where matching failed, returns nil if
+// no failure was recorded
+func (r *Reader) ParseError() *ParseError {
+	if r.failPos == nil {
+		return nil
+	}
+
+	e := &ParseError{
+		Pos:      r.failPos,
+		Expected: append([]string{}, r.expected...),
+	}
+
+	rn, _, err := r.src.read(r.failPos.absoluteCharPos, r.failPos.byteOffset)
+	if err == io.EOF {
+		e.EOF = true
+	} else if err != nil {
+		e.Found = utf8.RuneError
+	} else {
+		e.Found = rn
+	}
+
+	return e
+}
+
+// Parse matches pattern p against all input of reader r. If the pattern does not match or does not
+// consume all input, the match result is returned together with a *ParseError describing the
+// furthest failure. If no failure was recorded the error of the match result or a generic syntax error
+// is returned
+func Parse(p Pattern, r *Reader) (*MatchResult, error) {
+	result, err := p.Match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Match {
+		result.Node.AttachTrivia()
+	}
+
+	if result.Match && !r.Finished() {
+		r.expect(r.CurrentPosition(), "end of input")
+	}
+
+	if !result.Match || !r.Finished() {
+		if parseErr := r.ParseError(); parseErr != nil {
+			return result, parseErr
+		}
+
+		// A failed match without recorded expectations, for instance of a custom pattern
+		if result.Error != nil {
+			return result, result.Error
+		}
+
+		return result, errors.New("syntax error")
+	}
+
+	return result, nil
+}
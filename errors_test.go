@@ -0,0 +1,100 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	inputs := map[string]string{
+		"{\"a\": 1,\n \"b\": 2\n \"c\": 3}": `expected one of " \n\r\t", "," or "}" at line 3, col 2, found '"'`,
+		`{"a": [1, 2`:                       `expected "]" at line 1, col 12, found end of input`,
+		`[1,]`:                              `expected value at line 1, col 4, found ']'`,
+		`{"a": 1} x`:                        `expected one of " \n\r\t" or end of input at line 1, col 10, found 'x'`,
+	}
+
+	for input, expected := range inputs {
+		reader, _ := NewReader(strings.NewReader(input))
+
+		_, err := Parse(jsonGrammar(), reader)
+		if err == nil {
+			t.Errorf("input %q: expected error", input)
+			continue
+		}
+
+		parseError, ok := err.(*ParseError)
+		if !ok {
+			t.Fatalf("input %q: expected *ParseError, got %T", input, err)
+		}
+
+		if parseError.Error() != expected {
+			t.Errorf("input %q: expected error %q, got %q", input, expected, parseError.Error())
+		}
+	}
+
+	reader, _ := NewReader(strings.NewReader(`{"a": [1, 2]}`))
+
+	result, err := Parse(jsonGrammar(), reader)
+	if err != nil || !result.Match {
+		t.Errorf("expected match without error, got %v", err)
+	}
+}
+
+func TestParseErrorRuleNames(t *testing.T) {
+	grammar := NewGrammar().
+		Rule("assignment", NewConcatenation([]Pattern{Ref("identifier"), NewTerminalString("=", nil), Ref("value")}, nil)).
+		Rule("identifier", NewRepetition(NewCharacterRange('a', 'z', false, nil), 1, 0, nil)).
+		Rule("value", NewAlternation([]Pattern{Ref("identifier"), NewTerminalString("true", nil)}, nil))
+
+	reader, _ := NewReader(strings.NewReader("a=1"))
+
+	_, err := Parse(grammar, reader)
+	if err == nil || err.Error() != `expected value at line 1, col 3, found '1'` {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	parseError := reader.ParseError()
+	if parseError.Found != '1' || parseError.EOF || parseError.Pos.Offset() != 2 {
+		t.Errorf("unexpected parse error %+v", parseError)
+	}
+}
+
+func TestParseErrorWithoutExpectations(t *testing.T) {
+	reader, _ := NewReader(strings.NewReader("x"))
+
+	result, err := Parse(NewAlternation(nil, nil), reader)
+	if result == nil || result.Match {
+		t.Fatalf("expected failed match")
+	}
+
+	if err == nil || err.Error() != "syntax error" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestAlternationEndOfInput(t *testing.T) {
+	reader, _ := NewReader(strings.NewReader(""))
+
+	result, err := NewAlternation([]Pattern{NewOptional(NewTerminalString("a", nil), nil)}, nil).Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match {
+		t.Errorf("expected optional alternative to match at the end of input")
+	}
+
+	reader, _ = NewReader(strings.NewReader(""))
+
+	_, err = Parse(NewAlternation([]Pattern{NewTerminalString("a", nil), NewEOF(nil)}, nil), reader)
+	if err != nil {
+		t.Errorf("expected end of input alternative to match, got %v", err)
+	}
+
+	reader, _ = NewReader(strings.NewReader(""))
+
+	_, err = Parse(NewAlternation([]Pattern{NewTerminalString("a", nil), NewTerminalString("b", nil)}, nil), reader)
+	if err == nil || err.Error() != `expected "a" or "b" at line 1, col 1, found end of input` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package ebnf
+
+import (
+	"fmt"
+)
+
+// Grammar holds a set of named rules, rules reference each other by name and are resolved
+// lazily at match time so recursive rules do not need to be back-patched. Start holds the name
+// of the start rule, if empty the first defined rule is used. Warnings holds the issues found by
+// Check when the grammar is loaded by ParseGrammar
+type Grammar struct {
+	Start    string
+	Warnings []*Issue
+	rules    map[string]Pattern
+	names    []string
+	version  int
+}
+
+// NewGrammar creates a new empty grammar
+func NewGrammar() *Grammar {
+	return &Grammar{
+		rules: map[string]Pattern{},
+		names: []string{},
+	}
+}
+
+// define adds a named rule to the grammar, a rule can only be defined once
+func (g *Grammar) define(name string, p Pattern) error {
+	if _, ok := g.rules[name]; ok {
+		return fmt.Errorf("rule %q defined more than once", name)
+	}
+
+	g.rules[name] = p
+	g.names = append(g.names, name)
+	g.version++
+
+	return nil
+}
+
+// Rule adds a named rule to the grammar or replaces an existing rule with the same name,
+// returns the grammar so rules can be chained
+func (g *Grammar) Rule(name string, p Pattern) *Grammar {
+	if _, ok := g.rules[name]; !ok {
+		g.names = append(g.names, name)
+	}
+
+	g.rules[name] = p
+	g.version++
+
+	return g
+}
+
+// Ref creates a reference to a rule of this grammar, the reference can also be matched outside
+// of the grammar
+func (g *Grammar) Ref(name string) *Reference {
+	return &Reference{
+		Name:    name,
+		Grammar: g,
+	}
+}
+
+// Lookup returns the pattern for a named rule
+func (g *Grammar) Lookup(name string) (Pattern, bool) {
+	p, ok := g.rules[name]
+	return p, ok
+}
+
+// Names returns the rule names in order of definition
+func (g *Grammar) Names() []string {
+	return append([]string{}, g.names...)
+}
+
+// startName returns the name of the start rule, which is the first defined rule if Start is not set
+func (g *Grammar) startName() (string, error) {
+	if g.Start != "" {
+		return g.Start, nil
+	}
+
+	if len(g.names) == 0 {
+		return "", fmt.Errorf("grammar has no rules")
+	}
+
+	return g.names[0], nil
+}
+
+// Match the start rule of the grammar
+func (g *Grammar) Match(r *Reader) (*MatchResult, error) {
+	name, err := g.startName()
+	if err != nil {
+		return nil, err
+	}
+
+	return g.Ref(name).Match(r)
+}
+
+// Reference pattern matches a named grammar rule, the rule is looked up at match time. A reference
+// without a grammar resolves the rule in the grammar that is currently being matched
+type Reference struct {
+	Name    string
+	Grammar *Grammar
+}
+
+// Ref creates a reference to a named rule of the grammar that is being matched, this allows
+// patterns to be constructed without access to the grammar
+func Ref(name string) *Reference {
+	return &Reference{
+		Name: name,
+	}
+}
+
+// Match the referenced rule, MatchResult.Rule will be set to the name of the rule. If the rule
+// results in the result of another rule, for instance item = number | list, the result is wrapped in
+// a result of the rule with MatchResult.Inner holding the result of the other rule. Left recursive
+// rules are supported (see Reader.matchRule)
+func (ref *Reference) Match(r *Reader) (*MatchResult, error) {
+	g := ref.Grammar
+	if g == nil {
+		g = r.grammar
+	}
+
+	if g == nil {
+		return nil, fmt.Errorf("rule %q referenced outside of a grammar", ref.Name)
+	}
+
+	p, ok := g.rules[ref.Name]
+	if !ok {
+		return nil, fmt.Errorf("undefined rule %q", ref.Name)
+	}
+
+	// Number of expectations at the begin position before matching the rule
+	beginPos := r.CurrentPosition()
+	expected := 0
+
+	if r.failPos != nil && r.failPos.absoluteCharPos == beginPos.absoluteCharPos {
+		expected = len(r.expected)
+	}
+
+	// Unbound references inside the rule resolve to the grammar of the rule
+	prevGrammar := r.grammar
+	r.grammar = g
+
+	result, err := r.matchRule(g, ref.Name, p)
+
+	r.grammar = prevGrammar
+
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.Match {
+		// Report the rule as expected instead of the patterns of the rule
+		r.expectRule(beginPos, expected, ref.Name)
+	}
+
+	if result.Rule != "" && result.Rule != ref.Name {
+		wrapped := *result
+		wrapped.Inner = result
+		result = &wrapped
+	}
+
+	result.Rule = ref.Name
+
+	r.setRuleNode(ref.Name, result)
+
+	return result, nil
+}
@@ -0,0 +1,269 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestParseGrammar(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		(* a list of assignments *)
+		program = { assignment, ";" } ;
+		assignment = identifier, ":=", ( number | identifier | string ) ;
+		identifier = letter, { letter | digit } ;
+		number = [ "-" ], digit, { digit } ;
+		string = '"', { character - '"' }, '"' ;
+		letter = "a" | "b" | "c" | "x" | "y" | "z" ;
+		digit = "0" | "1" | "2" | "3" ;
+		character = letter | digit | " " ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if names := grammar.Names(); len(names) != 8 || names[0] != "program" {
+		t.Fatalf("unexpected rule names %v", names)
+	}
+
+	reader, err := NewReader(strings.NewReader(`abc:=-12;x:="a b";y:=x;`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	result, err := grammar.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() {
+		t.Fatalf("expected program to match all input")
+	}
+
+	if n := len(result.Result.([]*MatchResult)); n != 3 {
+		t.Errorf("expected 3 assignments, got %d", n)
+	}
+}
+
+func TestParseGrammarRecursion(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		nested = "(", [ nested ], ")" ;
+		pair = 2 * "ab" ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	for input, match := range map[string]bool{"((()))": true, "(()": false} {
+		reader, _ := NewReader(strings.NewReader(input))
+
+		result, err := grammar.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		if result.Match != match {
+			t.Errorf("input %q: expected match %v", input, match)
+		}
+	}
+
+	pair, _ := grammar.Lookup("pair")
+	reader, _ := NewReader(strings.NewReader("ababab"))
+
+	result, err := pair.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || reader.CurrentPosition().Offset() != 4 {
+		t.Errorf("expected exactly two repetitions to match")
+	}
+}
+
+func TestParseGrammarErrors(t *testing.T) {
+	inputs := map[string]string{
+		`a = "x" "y" ;`:    "invalid rule definition at line 1, pos 8",
+		`a = [ "x" ;`:      `expected "]" at line 1, pos 10`,
+		`a = "x" ; a = ;`:  `rule "a" defined more than once`,
+		`a = "x" ; = "y";`: "expected rule definition at line 1, pos 11",
+	}
+
+	for input, expected := range inputs {
+		_, err := ParseGrammar(strings.NewReader(input))
+		if err == nil || err.Error() != expected {
+			t.Errorf("input %q: expected error %q, got %v", input, expected, err)
+		}
+	}
+}
+
+func TestGrammarRules(t *testing.T) {
+	// Rules can be constructed without access to the grammar
+	list := func(element Pattern) Pattern {
+		return NewConcatenation(
+			[]Pattern{element, NewAny(NewConcatenation([]Pattern{NewTerminalString(",", nil), element}, nil), nil)},
+			nil,
+		)
+	}
+
+	grammar := NewGrammar().
+		Rule("list", NewConcatenation([]Pattern{NewTerminalString("[", nil), list(Ref("value")), NewTerminalString("]", nil)}, nil)).
+		Rule("value", NewAlternation([]Pattern{Ref("number"), Ref("list")}, nil)).
+		Rule("number", NewRepetition(NewCharacterRange('0', '9', false, nil), 1, 0, nil))
+
+	grammar.Start = "value"
+
+	reader, _ := NewReader(strings.NewReader("[1,[2,3],[[4]]]"))
+
+	result, err := grammar.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() {
+		t.Fatalf("expected value to match all input")
+	}
+
+	if result.Rule != "value" {
+		t.Errorf("expected rule value, got %q", result.Rule)
+	}
+
+	elements := result.Result.([]*MatchResult)[1].Result.([]*MatchResult)
+	if elements[0].Rule != "value" {
+		t.Errorf("expected rule value, got %q", elements[0].Rule)
+	}
+
+	// The value wraps the result of the number rule
+	if inner := elements[0].Inner; inner == nil || inner.Rule != "number" || reader.StringFromResult(inner) != "1" {
+		t.Errorf("expected inner number result")
+	}
+
+	if _, err = Ref("value").Match(reader); err == nil {
+		t.Errorf("expected error for reference outside of a grammar")
+	}
+
+	if _, err = grammar.Ref("missing").Match(reader); err == nil {
+		t.Errorf("expected error for undefined rule")
+	}
+}
+
+func TestParseGrammarSpecialSequence(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		(* a simple program syntax in EBNF - Wikipedia *)
+		program = 'PROGRAM', white space, identifier, white space,
+		          'BEGIN', white space,
+		          { assignment, ";", white space },
+		          'END.' ;
+		identifier = alphabetic character, { alphabetic character | digit } ;
+		number = [ "-" ], digit, { digit } ;
+		string = '"' , { all characters - '"' }, '"' ;
+		assignment = identifier , ":=" , ( number | identifier | string ) ;
+		alphabetic character = ? upper case letter ? ;
+		digit = ? decimal digit ? ;
+		white space = ? white space characters ? ;
+		all characters = ? all visible characters ? ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	grammar.
+		Rule("upper case letter", NewCharacterRange('A', 'Z', false, nil)).
+		Rule("decimal digit", NewCharacterRange('0', '9', false, nil)).
+		Rule("white space characters", NewRepetition(NewCharacterGroup(unicode.IsSpace, false, nil), 1, 0, nil)).
+		Rule("all visible characters", NewCharacterGroup(unicode.IsPrint, false, nil))
+
+	reader, _ := NewReader(strings.NewReader("PROGRAM DEMO1\nBEGIN\n  A:=3;\n  TEXT:=\"Hello world!\";\nEND."))
+
+	result, err := grammar.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() || result.Rule != "program" {
+		t.Errorf("expected program to match all input")
+	}
+}
+
+func TestLeftRecursion(t *testing.T) {
+	operation := func(op string, f func(a int, b int) int) Pattern {
+		return NewConcatenation([]Pattern{Ref("expression"), NewTerminalString(op, nil), Ref("term")}, func(m *MatchResult, r *Reader) error {
+			if m.Match {
+				params := m.Result.([]*MatchResult)
+				m.Result = f(params[0].Result.(int), params[2].Result.(int))
+			}
+			return nil
+		})
+	}
+
+	grammar := NewGrammar().
+		Rule("expression", NewAlternation(
+			[]Pattern{
+				operation("+", func(a int, b int) int { return a + b }),
+				operation("-", func(a int, b int) int { return a - b }),
+				Ref("term"),
+			},
+			nil,
+		)).
+		Rule("term", NewCharacterRange('0', '9', false, func(m *MatchResult, r *Reader) error {
+			if m.Match {
+				m.Result = int(m.Result.(string)[0] - '0')
+			}
+			return nil
+		}))
+
+	for _, memoize := range []bool{false, true} {
+		reader, _ := NewReader(strings.NewReader("9-2-3+1"))
+		reader.SetMemoization(memoize)
+
+		result, err := grammar.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		if !result.Match || !reader.Finished() {
+			t.Fatalf("expected expression to match all input")
+		}
+
+		// Left associative: ((9 - 2) - 3) + 1
+		if result.Result.(int) != 5 {
+			t.Errorf("expected 5, got %v", result.Result)
+		}
+	}
+}
+
+func TestIndirectLeftRecursion(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		list = item, "," | "x" ;
+		item = list, "y" | list ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	reader, _ := NewReader(strings.NewReader("xy,,y,"))
+
+	result, err := grammar.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() {
+		t.Fatalf("expected list to match all input")
+	}
+
+	// The left most item is the most deeply nested
+	depth := 0
+	for result.Rule == "list" || result.Rule == "item" {
+		params, ok := result.Result.([]*MatchResult)
+		if !ok {
+			break
+		}
+
+		result = params[0]
+		depth++
+	}
+
+	if depth != 5 || result.Result != "x" {
+		t.Errorf("unexpected left recursive tree, depth %d", depth)
+	}
+}
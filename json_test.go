@@ -310,6 +310,32 @@ func jsonObjectPattern(value Pattern, str Pattern, whitespace Pattern) Pattern {
 	)
 }
 
+func jsonGrammar() *Grammar {
+	whitespacePattern := NewAny(NewCharacterEnum(" \n\r\t", false, nil), jsonWhitespaceTransform)
+	valuePattern := Ref("value")
+
+	return NewGrammar().
+		Rule("json", NewConcatenation([]Pattern{valuePattern, NewEOF(nil)}, nil)).
+		Rule("value", NewConcatenation(
+			[]Pattern{
+				whitespacePattern,
+				NewAlternation(
+					[]Pattern{Ref("string"), Ref("number"), Ref("object"), Ref("array"), Ref("true"), Ref("false"), Ref("null")},
+					nil,
+				),
+				whitespacePattern,
+			},
+			jsonValueTransform,
+		)).
+		Rule("string", jsonStringPattern()).
+		Rule("number", jsonNumberPattern()).
+		Rule("object", jsonObjectPattern(valuePattern, Ref("string"), whitespacePattern)).
+		Rule("array", jsonArrayPattern(valuePattern, whitespacePattern)).
+		Rule("true", NewTerminalString("true", jsonTrueTransform)).
+		Rule("false", NewTerminalString("false", jsonFalseTransform)).
+		Rule("null", NewTerminalString("null", jsonNullTransform))
+}
+
 func TestJSON(t *testing.T) {
 	file, err := os.Open("test2.json")
 	if err != nil {
@@ -360,3 +386,35 @@ func TestJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestJSONGrammar(t *testing.T) {
+	file, err := os.Open("test2.json")
+	if err != nil {
+		t.Errorf("err %v", err)
+		t.FailNow()
+	}
+
+	defer file.Close()
+
+	// strings.NewReader(`{"a" : 1, "b" : 2}`)
+
+	reader, err := NewReader(bufio.NewReader(file))
+	if err != nil {
+		t.Errorf("err %v", err)
+		t.FailNow()
+	}
+
+	result, err := jsonGrammar().Match(reader)
+	if err != nil {
+		log.Fatalf("err %v\n", err)
+	}
+
+	if result.Match {
+		log.Printf("match: %v", result.Result.([]*MatchResult)[0].Result)
+	} else {
+		deepest := reader.DeepestError()
+		if deepest.Error != nil {
+			log.Printf("err result: %v %v\n", deepest.Error, deepest.RangeString())
+		}
+	}
+}
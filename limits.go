@@ -0,0 +1,144 @@
+package ebnf
+
+import (
+	"context"
+	"fmt"
+)
+
+// contextCheckInterval is the number of steps between checks of the context
+const contextCheckInterval = 256
+
+// Limits holds the resource limits of a parse, a zero value means no limit
+type Limits struct {
+	// MaxSteps is the maximum number of pattern match attempts of Alternation, Concatenation,
+	// Repetition, Exception, OperatorTable and Recovery patterns
+	MaxSteps int
+	// MaxDepth is the maximum nesting depth of pattern match attempts
+	MaxDepth int
+	// MaxInputSize is the maximum number of input bytes that is read. It only bounds the memory of a
+	// reader created with NewStreamingReader, NewReader and NewBytesReader hold all input before the
+	// parse starts. Wrap the input of NewReader in an io.LimitReader to bound its memory
+	MaxInputSize int
+	// MaxMemoBytes is the maximum estimated size of the memo table (see MemoStats)
+	MaxMemoBytes int
+}
+
+// Limit identifies the limit that was exceeded
+type Limit int
+
+const (
+	// StepLimit is exceeded if more than Limits.MaxSteps match attempts are made
+	StepLimit Limit = iota
+	// DepthLimit is exceeded if match attempts are nested deeper than Limits.MaxDepth
+	DepthLimit
+	// InputSizeLimit is exceeded if more than Limits.MaxInputSize bytes are read
+	InputSizeLimit
+	// MemoLimit is exceeded if the memo table grows beyond Limits.MaxMemoBytes
+	MemoLimit
+	// ContextLimit is reached if the context is canceled or its deadline is exceeded
+	ContextLimit
+)
+
+// String returns the description of a limit
+func (l Limit) String() string {
+	switch l {
+	case StepLimit:
+		return "step limit"
+	case DepthLimit:
+		return "depth limit"
+	case InputSizeLimit:
+		return "input size limit"
+	case MemoLimit:
+		return "memo limit"
+	case ContextLimit:
+		return "context"
+	}
+
+	return fmt.Sprintf("limit(%d)", int(l))
+}
+
+// LimitError is returned when a parse is aborted because a limit was exceeded or the context is done,
+// Pos holds the position the reader reached and Err holds the context error
+type LimitError struct {
+	Limit Limit
+	Pos   *ReaderPos
+	Err   error
+}
+
+// Error returns the limit error message with position
+func (e *LimitError) Error() string {
+	message := fmt.Sprintf("%v exceeded", e.Limit)
+	if e.Err != nil {
+		message = fmt.Sprintf("parse aborted: %v", e.Err)
+	}
+
+	return fmt.Sprintf("%v at line %d, col %d", message, e.Pos.Line(), e.Pos.Column())
+}
+
+// Unwrap returns the context error
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// ParseContext parses the input like Parse, but aborts with a *LimitError when one of the limits is
+// exceeded or the context is done. Patterns return the error from Match, so the parse stops immediately
+func ParseContext(ctx context.Context, p Pattern, r *Reader, limits Limits) (*MatchResult, error) {
+	r.ctx = ctx
+	r.limits = limits
+	r.steps = 0
+	r.depth = 0
+
+	defer func() {
+		r.ctx = nil
+	}()
+
+	return Parse(p, r)
+}
+
+// limitError creates a limit error at the current position
+func (r *Reader) limitError(limit Limit, err error) *LimitError {
+	return &LimitError{
+		Limit: limit,
+		Pos:   r.CurrentPosition(),
+		Err:   err,
+	}
+}
+
+// invoke calls the match function of a composite pattern, checks the limits of the parse before
+// the pattern is matched and memoizes the result
+func (r *Reader) invoke(p Pattern, match func(r *Reader) (*MatchResult, error)) (*MatchResult, error) {
+	if r.ctx == nil {
+		return r.memoize(p, match)
+	}
+
+	if r.steps%contextCheckInterval == 0 {
+		if err := r.ctx.Err(); err != nil {
+			return nil, r.limitError(ContextLimit, err)
+		}
+	}
+
+	r.steps++
+	if r.limits.MaxSteps > 0 && r.steps > r.limits.MaxSteps {
+		return nil, r.limitError(StepLimit, nil)
+	}
+
+	r.depth++
+	defer func() {
+		r.depth--
+	}()
+
+	if r.limits.MaxDepth > 0 && r.depth > r.limits.MaxDepth {
+		return nil, r.limitError(DepthLimit, nil)
+	}
+
+	result, err := r.memoize(p, match)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.limits.MaxMemoBytes > 0 && len(r.memo)*memoEntrySize > r.limits.MaxMemoBytes {
+		return nil, r.limitError(MemoLimit, nil)
+	}
+
+	return result, nil
+}
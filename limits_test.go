@@ -0,0 +1,97 @@
+package ebnf
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseContextLimits(t *testing.T) {
+	grammar := NewGrammar().
+		Rule("nested", NewAlternation([]Pattern{
+			NewConcatenation([]Pattern{NewTerminalString("(", nil), Ref("nested"), NewTerminalString(")", nil)}, nil),
+			NewTerminalString("x", nil),
+		}, nil))
+
+	input := strings.Repeat("(", 100) + "x" + strings.Repeat(")", 100)
+
+	tests := []struct {
+		limits Limits
+		limit  Limit
+		memo   bool
+	}{
+		{Limits{MaxDepth: 50}, DepthLimit, false},
+		{Limits{MaxSteps: 100}, StepLimit, false},
+		{Limits{MaxInputSize: 150}, InputSizeLimit, false},
+		{Limits{MaxMemoBytes: 10 * memoEntrySize}, MemoLimit, true},
+	}
+
+	for _, test := range tests {
+		reader, _ := NewReader(strings.NewReader(input))
+		reader.SetMemoization(test.memo)
+
+		_, err := ParseContext(context.Background(), grammar, reader, test.limits)
+
+		var limitErr *LimitError
+		if !errors.As(err, &limitErr) || limitErr.Limit != test.limit {
+			t.Errorf("expected %v to be exceeded, got %v", test.limit, err)
+			continue
+		}
+
+		if limitErr.Pos == nil || limitErr.Pos.Offset() == 0 {
+			t.Errorf("expected %v to be exceeded after reading input", test.limit)
+		}
+	}
+
+	// The limits are high enough
+	reader, _ := NewReader(strings.NewReader(input))
+
+	result, err := ParseContext(context.Background(), grammar, reader, Limits{MaxDepth: 1000, MaxSteps: 1000, MaxInputSize: 201})
+	if err != nil || !result.Match {
+		t.Errorf("expected input to match, got %v", err)
+	}
+}
+
+func TestParseContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	reader, _ := NewReader(strings.NewReader("aaa"))
+
+	_, err := ParseContext(ctx, NewAny(NewTerminalString("a", nil), nil), reader, Limits{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+
+	if err.Error() != "parse aborted: context canceled at line 1, col 1" {
+		t.Errorf("unexpected error message %q", err.Error())
+	}
+}
+
+// endlessReader reads an endless sequence of a
+type endlessReader struct{}
+
+func (endlessReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 'a'
+	}
+
+	return len(p), nil
+}
+
+func TestParseContextStreamingInputSize(t *testing.T) {
+	reader := NewStreamingReader(endlessReader{})
+
+	_, err := ParseContext(context.Background(), NewAny(NewTerminalString("a", nil), nil), reader, Limits{MaxInputSize: 100000})
+
+	var limitErr *LimitError
+	if !errors.As(err, &limitErr) || limitErr.Limit != InputSizeLimit {
+		t.Fatalf("expected input size limit to be exceeded, got %v", err)
+	}
+
+	// The streaming reader only buffered the input up to the limit
+	if n := len(reader.src.(*streamSource).buf); n > 2*discardChunk {
+		t.Errorf("expected input to be discarded, %d runes buffered", n)
+	}
+}
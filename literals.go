@@ -0,0 +1,186 @@
+package ebnf
+
+import (
+	"io"
+	"strconv"
+	"unicode"
+)
+
+// LiteralOptions holds the options of a literal set
+type LiteralOptions struct {
+	// CaseInsensitive matches literals using Unicode simple case folding
+	CaseInsensitive bool
+	// WordBoundary, if set, rejects a literal that is followed by a rune in the group, so a keyword
+	// does not match the start of an identifier
+	WordBoundary CharacterGroupFunction
+}
+
+// literalNode is a node of the trie of a literal set
+type literalNode struct {
+	children map[rune]*literalNode
+	literal  string
+	terminal bool
+}
+
+// LiteralSet pattern, matches the longest of a set of literal strings
+type LiteralSet struct {
+	BaseTransformer
+	Literals []string
+	Options  LiteralOptions
+	root     *literalNode
+}
+
+// NewLiteralSet creates a new literal set, the set can not be changed after it is created. If literals
+// are equal (ignoring case for a case insensitive set) the first literal is used
+func NewLiteralSet(literals []string, opts LiteralOptions, t TransformFunction) *LiteralSet {
+	s := &LiteralSet{
+		BaseTransformer: BaseTransformer{
+			T: t,
+		},
+		Literals: append([]string{}, literals...),
+		Options:  opts,
+		root:     &literalNode{children: map[rune]*literalNode{}},
+	}
+
+	for _, literal := range s.Literals {
+		node := s.root
+
+		for _, rn := range literal {
+			rn = s.key(rn)
+
+			child, ok := node.children[rn]
+			if !ok {
+				child = &literalNode{children: map[rune]*literalNode{}}
+				node.children[rn] = child
+			}
+
+			node = child
+		}
+
+		if !node.terminal {
+			node.terminal = true
+			node.literal = literal
+		}
+	}
+
+	return s
+}
+
+// foldRune returns the smallest rune that is equivalent to rn under Unicode simple case folding
+func foldRune(rn rune) rune {
+	folded := rn
+
+	for f := unicode.SimpleFold(rn); f != rn; f = unicode.SimpleFold(f) {
+		if f < folded {
+			folded = f
+		}
+	}
+
+	return folded
+}
+
+// key returns the trie key of a rune
+func (s *LiteralSet) key(rn rune) rune {
+	if s.Options.CaseInsensitive {
+		return foldRune(rn)
+	}
+
+	return rn
+}
+
+// boundary returns true if the reader is at a word boundary
+func (s *LiteralSet) boundary(r *Reader) (bool, error) {
+	if s.Options.WordBoundary == nil {
+		return true, nil
+	}
+
+	rn, err := r.Peak()
+	if err == io.EOF {
+		return true, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return !s.Options.WordBoundary(rn), nil
+}
+
+// Match the longest literal of the set, MatchResult.Result will contain the literal as given to
+// NewLiteralSet, which can differ from the input for a case insensitive set
+func (s *LiteralSet) Match(r *Reader) (*MatchResult, error) {
+	beginPos := r.CurrentPosition()
+
+	r.PushState()
+
+	result := &MatchResult{Match: false}
+	result.BeginPos = beginPos
+
+	var longest *literalNode
+	var longestPos *ReaderPos
+
+	node := s.root
+
+	for node != nil {
+		if node.terminal {
+			ok, err := s.boundary(r)
+			if err != nil {
+				return nil, err
+			}
+
+			if ok {
+				longest = node
+				longestPos = r.CurrentPosition()
+			}
+		}
+
+		if len(node.children) == 0 {
+			break
+		}
+
+		rn, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		node = node.children[s.key(rn)]
+	}
+
+	if longest == nil {
+		for _, literal := range s.Literals {
+			r.expect(beginPos, strconv.Quote(literal))
+		}
+
+		result.EndPos = r.CurrentPosition()
+
+		err := s.Transform(result, r)
+		if err != nil {
+			return nil, err
+		}
+
+		r.RestoreState()
+
+		return result, nil
+	}
+
+	r.seek(longestPos)
+
+	result.Match = true
+	result.EndPos = longestPos
+	result.Result = longest.literal
+
+	r.setNode(TerminalNode, result, nil)
+
+	err := s.Transform(result, r)
+	if err != nil {
+		return nil, err
+	}
+
+	r.PopState()
+
+	return result, nil
+}
@@ -0,0 +1,77 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestLiteralSet(t *testing.T) {
+	operators := NewLiteralSet([]string{"=", ":=", "==", "<", "<=", "<<="}, LiteralOptions{}, nil)
+
+	inputs := map[string]string{
+		"==x":  "==",
+		"=x":   "=",
+		":=":   ":=",
+		"<<=1": "<<=",
+		"<<1":  "<",
+		"<=":   "<=",
+	}
+
+	for input, expected := range inputs {
+		reader, _ := NewReader(strings.NewReader(input))
+
+		result, err := operators.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		if !result.Match || result.Result != expected || reader.CurrentPosition().Offset() != len(expected) {
+			t.Errorf("input %q: expected %q, got %v", input, expected, result.Result)
+		}
+	}
+
+	reader, _ := NewReader(strings.NewReader(":x"))
+
+	_, err := Parse(operators, reader)
+	if err == nil || err.Error() != `expected "=", ":=", "==", "<", "<=" or "<<=" at line 1, col 1, found ':'` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestLiteralSetKeywords(t *testing.T) {
+	identifier := func(rn rune) bool {
+		return unicode.IsLetter(rn) || unicode.IsDigit(rn) || rn == '_'
+	}
+
+	keywords := NewLiteralSet([]string{"SELECT", "FROM", "WHERE", "IN", "INSERT", "INTO"}, LiteralOptions{
+		CaseInsensitive: true,
+		WordBoundary:    identifier,
+	}, nil)
+
+	inputs := map[string]interface{}{
+		"select *": "SELECT",
+		"Into":     "INTO",
+		"in (":     "IN",
+		"inner":    nil,
+		"selects":  nil,
+		"fromage":  nil,
+	}
+
+	for input, expected := range inputs {
+		reader, _ := NewReader(strings.NewReader(input))
+
+		result, err := keywords.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		if expected == nil {
+			if result.Match || reader.CurrentPosition().Offset() != 0 {
+				t.Errorf("input %q: expected no match", input)
+			}
+		} else if !result.Match || result.Result != expected {
+			t.Errorf("input %q: expected %q, got %v", input, expected, result.Result)
+		}
+	}
+}
@@ -0,0 +1,319 @@
+package ebnf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ebnfRule holds a compiled rule definition
+type ebnfRule struct {
+	name    string
+	pattern Pattern
+}
+
+func ebnfAnyRune(r rune) bool {
+	return true
+}
+
+func ebnfIdentifierTransform(m *MatchResult, r *Reader) error {
+	if m.Match {
+		// Meta identifiers may consist of multiple words, normalize the gaps to a single space
+		m.Result = strings.Join(strings.Fields(r.StringFromResult(m)), " ")
+	}
+
+	return nil
+}
+
+func ebnfQuotedTransform(m *MatchResult, r *Reader) error {
+	if m.Match {
+		m.Result = r.StringFromResult(m.Result.([]*MatchResult)[1])
+	}
+
+	return nil
+}
+
+func ebnfIntegerTransform(m *MatchResult, r *Reader) error {
+	if !m.Match {
+		return nil
+	}
+
+	value, err := strconv.Atoi(r.StringFromResult(m))
+	if err != nil {
+		return err
+	}
+
+	m.Result = value
+
+	return nil
+}
+
+func ebnfRuleTransform(m *MatchResult, r *Reader) error {
+	if !m.Match {
+		if m.PartialMatch {
+			m.Error = errors.New("invalid rule definition")
+			r.PushError(m)
+		}
+		return nil
+	}
+
+	params := m.Result.([]*MatchResult)
+
+	m.Result = &ebnfRule{
+		name:    params[0].Result.(string),
+		pattern: params[2].Result.(Pattern),
+	}
+
+	return nil
+}
+
+// ebnfSyntax returns the pattern for ISO/IEC 14977 EBNF text, rules are compiled to patterns
+// with references resolved lazily through grammar g
+func ebnfSyntax(g *Grammar) Pattern {
+	meta := NewGrammar()
+
+	commentEnd := NewTerminalString("*)", nil)
+	comment := NewConcatenation(
+		[]Pattern{
+			NewTerminalString("(*", nil),
+			NewAny(NewException(NewCharacterGroup(ebnfAnyRune, false, nil), commentEnd, nil), nil),
+			commentEnd,
+		},
+		nil,
+	)
+
+	ws := NewAny(NewAlternation([]Pattern{NewCharacterGroup(unicode.IsSpace, false, nil), comment}, nil), nil)
+
+	// Skip leading whitespace and comments, the result of the pattern becomes the lexeme result
+	lexeme := func(p Pattern) Pattern {
+		return NewConcatenation([]Pattern{ws, p}, func(m *MatchResult, r *Reader) error {
+			if m.Match {
+				m.Result = m.Result.([]*MatchResult)[1].Result
+			}
+			return nil
+		})
+	}
+
+	symbol := func(s string) Pattern {
+		return lexeme(NewTerminalString(s, nil))
+	}
+
+	quoted := func(quote string) Pattern {
+		q := NewTerminalString(quote, nil)
+		return NewConcatenation(
+			[]Pattern{q, NewAny(NewException(NewCharacterGroup(ebnfAnyRune, false, nil), q, nil), nil), q},
+			ebnfQuotedTransform,
+		)
+	}
+
+	letter := NewCharacterGroup(unicode.IsLetter, false, nil)
+	digit := NewCharacterGroup(unicode.IsDigit, false, nil)
+	word := NewConcatenation(
+		[]Pattern{letter, NewAny(NewAlternation([]Pattern{letter, digit, NewTerminalString("_", nil)}, nil), nil)},
+		nil,
+	)
+	identifier := lexeme(NewConcatenation(
+		[]Pattern{
+			word,
+			NewAny(NewConcatenation([]Pattern{NewRepetition(NewCharacterEnum(" \t", false, nil), 1, 0, nil), word}, nil), nil),
+		},
+		ebnfIdentifierTransform,
+	))
+
+	integer := lexeme(NewRepetition(digit, 1, 0, ebnfIntegerTransform))
+
+	// Grouped sequences return the pattern of the enclosed definitions list
+	enclosed := func(open string, close string, t func(p Pattern) Pattern) Pattern {
+		return NewConcatenation(
+			[]Pattern{symbol(open), meta.Ref("definitions"), symbol(close)},
+			func(m *MatchResult, r *Reader) error {
+				if m.Match {
+					m.Result = t(m.Result.([]*MatchResult)[1].Result.(Pattern))
+				} else if m.PartialMatch {
+					m.Error = fmt.Errorf("expected %q", close)
+					r.PushError(m)
+				}
+				return nil
+			},
+		)
+	}
+
+	primary := NewAlternation(
+		[]Pattern{
+			enclosed("[", "]", func(p Pattern) Pattern { return NewOptional(p, nil) }),
+			enclosed("{", "}", func(p Pattern) Pattern { return NewAny(p, nil) }),
+			enclosed("(", ")", func(p Pattern) Pattern { return p }),
+			lexeme(NewConcatenation(
+				[]Pattern{NewTerminalString("?", nil), NewAny(NewException(NewCharacterGroup(ebnfAnyRune, false, nil), NewTerminalString("?", nil), nil), nil), NewTerminalString("?", nil)},
+				func(m *MatchResult, r *Reader) error {
+					if m.Match {
+						// Special sequences refer to rules which are added to the grammar by hand
+						name := strings.Join(strings.Fields(r.StringFromResult(m.Result.([]*MatchResult)[1])), " ")
+						m.Result = g.Ref(name)
+					}
+					return nil
+				},
+			)),
+			lexeme(NewAlternation([]Pattern{quoted("'"), quoted(`"`)}, func(m *MatchResult, r *Reader) error {
+				if m.Match {
+					m.Result = NewTerminalString(m.Result.(string), nil)
+				}
+				return nil
+			})),
+			NewConcatenation([]Pattern{identifier}, func(m *MatchResult, r *Reader) error {
+				if m.Match {
+					m.Result = g.Ref(m.Result.([]*MatchResult)[0].Result.(string))
+				}
+				return nil
+			}),
+			// Empty sequence
+			NewConcatenation(nil, func(m *MatchResult, r *Reader) error {
+				if m.Match {
+					m.Result = NewConcatenation(nil, nil)
+				}
+				return nil
+			}),
+		},
+		nil,
+	)
+
+	factor := NewConcatenation(
+		[]Pattern{
+			NewOptional(NewConcatenation([]Pattern{integer, symbol("*")}, nil), nil),
+			primary,
+		},
+		func(m *MatchResult, r *Reader) error {
+			if !m.Match {
+				return nil
+			}
+
+			params := m.Result.([]*MatchResult)
+			p := params[1].Result.(Pattern)
+
+			if count := params[0].Result.([]*MatchResult); len(count) > 0 {
+				n := count[0].Result.([]*MatchResult)[0].Result.(int)
+				p = NewRepetition(p, n, n, nil)
+			}
+
+			m.Result = p
+
+			return nil
+		},
+	)
+
+	term := NewConcatenation(
+		[]Pattern{
+			factor,
+			NewOptional(NewConcatenation([]Pattern{symbol("-"), factor}, nil), nil),
+		},
+		func(m *MatchResult, r *Reader) error {
+			if !m.Match {
+				return nil
+			}
+
+			params := m.Result.([]*MatchResult)
+			p := params[0].Result.(Pattern)
+
+			if except := params[1].Result.([]*MatchResult); len(except) > 0 {
+				p = NewException(p, except[0].Result.([]*MatchResult)[1].Result.(Pattern), nil)
+			}
+
+			m.Result = p
+
+			return nil
+		},
+	)
+
+	// Collect a separated list of patterns, a single pattern is returned as is
+	list := func(p Pattern, separator string, t func(patterns []Pattern) Pattern) Pattern {
+		return NewConcatenation(
+			[]Pattern{p, NewAny(NewConcatenation([]Pattern{symbol(separator), p}, nil), nil)},
+			func(m *MatchResult, r *Reader) error {
+				if !m.Match {
+					return nil
+				}
+
+				params := m.Result.([]*MatchResult)
+				patterns := []Pattern{params[0].Result.(Pattern)}
+
+				for _, next := range params[1].Result.([]*MatchResult) {
+					patterns = append(patterns, next.Result.([]*MatchResult)[1].Result.(Pattern))
+				}
+
+				if len(patterns) == 1 {
+					m.Result = patterns[0]
+				} else {
+					m.Result = t(patterns)
+				}
+
+				return nil
+			},
+		)
+	}
+
+	definition := list(term, ",", func(patterns []Pattern) Pattern { return NewConcatenation(patterns, nil) })
+	definitions := list(definition, "|", func(patterns []Pattern) Pattern { return NewAlternation(patterns, nil) })
+
+	meta.Rule("definitions", definitions)
+
+	rule := NewConcatenation(
+		[]Pattern{
+			identifier,
+			symbol("="),
+			definitions,
+			NewAlternation([]Pattern{symbol(";"), symbol(".")}, nil),
+		},
+		ebnfRuleTransform,
+	)
+
+	return NewConcatenation([]Pattern{NewAny(rule, nil), ws}, nil)
+}
+
+// ParseGrammar reads ISO/IEC 14977 EBNF text and compiles each rule to a pattern. Concatenation (,),
+// alternation (|), optional ([ ]), repeated ({ }) and grouped (( )) sequences, exceptions (-) and
+// integer repetitions (n *) are supported. A special sequence (? name ?) refers to a rule with the
+// given name that needs to be added to the grammar by hand. The first rule becomes the start rule.
+// Repetitions of patterns that can match empty input are reported in Grammar.Warnings (see Check), they
+// are not an error as a repetition stops after an empty iteration
+func ParseGrammar(input io.Reader) (*Grammar, error) {
+	r, err := NewReader(input)
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewGrammar()
+
+	result, err := ebnfSyntax(g).Match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !r.Finished() {
+		pos := r.CurrentPosition()
+		message := "expected rule definition"
+
+		deepest := r.DeepestError()
+		if deepest != nil && deepest.EndPos.absoluteCharPos >= pos.absoluteCharPos {
+			pos = deepest.EndPos
+			message = deepest.Error.Error()
+		}
+
+		return nil, fmt.Errorf("%v at line %d, pos %d", message, pos.linePos+1, pos.relativeCharPos+1)
+	}
+
+	for _, ruleResult := range result.Result.([]*MatchResult)[0].Result.([]*MatchResult) {
+		rule := ruleResult.Result.(*ebnfRule)
+
+		err = g.define(rule.name, rule.pattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	g.Warnings = g.Check()
+
+	return g, nil
+}
@@ -0,0 +1,107 @@
+package ebnf
+
+// And pattern, positive lookahead which matches if the pattern matches without consuming input
+type And struct {
+	BaseTransformer
+	Pattern Pattern
+}
+
+// NewAnd creates a new positive lookahead
+func NewAnd(p Pattern, t TransformFunction) *And {
+	return &And{
+		BaseTransformer: BaseTransformer{
+			T: t,
+		},
+		Pattern: p,
+	}
+}
+
+// Match positive lookahead, MatchResult.Result will contain the match result of the pattern
+func (a *And) Match(r *Reader) (*MatchResult, error) {
+	beginPos := r.CurrentPosition()
+
+	r.PushState()
+
+	result, err := a.Pattern.Match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	r.RestoreState()
+
+	result = &MatchResult{
+		BeginPos: beginPos,
+		EndPos:   beginPos,
+		Match:    result.Match,
+		Result:   result,
+	}
+
+	err = a.Transform(result, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Not pattern, negative lookahead which matches if the pattern does not match, never consumes input
+type Not struct {
+	BaseTransformer
+	Pattern Pattern
+}
+
+// NewNot creates a new negative lookahead
+func NewNot(p Pattern, t TransformFunction) *Not {
+	return &Not{
+		BaseTransformer: BaseTransformer{
+			T: t,
+		},
+		Pattern: p,
+	}
+}
+
+// Match negative lookahead, if the lookahead fails MatchResult.Failed will contain the match result
+// of the pattern
+func (n *Not) Match(r *Reader) (*MatchResult, error) {
+	beginPos := r.CurrentPosition()
+
+	// Failures of the pattern are expected so they are not reported in parse errors
+	failPos := r.failPos
+	expected := r.expected
+
+	r.PushState()
+
+	result, err := n.Pattern.Match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	r.RestoreState()
+
+	r.failPos = failPos
+	r.expected = expected
+
+	if result.Match {
+		result = &MatchResult{
+			BeginPos: beginPos,
+			EndPos:   beginPos,
+			Match:    false,
+			Failed:   result,
+		}
+
+		r.expect(beginPos, "")
+	} else {
+		result = &MatchResult{
+			BeginPos: beginPos,
+			EndPos:   beginPos,
+			Match:    true,
+		}
+	}
+
+	err = n.Transform(result, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookahead(t *testing.T) {
+	identifierChar := NewCharacterRange('a', 'z', false, nil)
+	keyword := NewConcatenation([]Pattern{NewTerminalString("if", nil), NewNot(identifierChar, nil)}, nil)
+
+	for input, match := range map[string]bool{"if x": true, "if": true, "iffy": false, "i": false} {
+		reader, _ := NewReader(strings.NewReader(input))
+
+		result, err := keyword.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		if result.Match != match {
+			t.Errorf("input %q: expected match %v", input, match)
+		}
+
+		if match && reader.CurrentPosition().Offset() != 2 {
+			t.Errorf("input %q: expected lookahead not to consume input", input)
+		}
+	}
+
+	call := NewConcatenation(
+		[]Pattern{
+			NewRepetition(identifierChar, 1, 0, nil),
+			NewAnd(NewTerminalString("(", nil), nil),
+		},
+		nil,
+	)
+
+	reader, _ := NewReader(strings.NewReader("print(x)"))
+
+	result, err := call.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || reader.CurrentPosition().Offset() != 5 {
+		t.Errorf("expected match up to the parenthesis")
+	}
+
+	lookahead := result.Result.([]*MatchResult)[1]
+	if lookahead.Span().Len() != 0 || lookahead.Result.(*MatchResult).Result != "(" {
+		t.Errorf("expected zero width lookahead containing the match")
+	}
+}
+
+func TestNotParseError(t *testing.T) {
+	keyword := NewConcatenation(
+		[]Pattern{NewTerminalString("if", nil), NewNot(NewCharacterRange('a', 'z', false, nil), nil), NewTerminalString(" then", nil)},
+		nil,
+	)
+
+	reader, _ := NewReader(strings.NewReader("if else"))
+
+	_, err := Parse(keyword, reader)
+	if err == nil || err.Error() != `expected " then" at line 1, col 3, found ' '` {
+		t.Errorf("unexpected error %v", err)
+	}
+}
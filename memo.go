@@ -0,0 +1,98 @@
+package ebnf
+
+import (
+	"unsafe"
+)
+
+// memoKey identifies a pattern match attempt at a position
+type memoKey struct {
+	pattern Pattern
+	grammar *Grammar
+	pos     int
+}
+
+// memoEntry holds a memoized match result and the reader position after the match
+type memoEntry struct {
+	result *MatchResult
+	end    *ReaderPos
+}
+
+// memoEntrySize is the estimated number of bytes used by a single memo table entry
+const memoEntrySize = int(unsafe.Sizeof(memoKey{})+unsafe.Sizeof(memoEntry{})+unsafe.Sizeof(MatchResult{})) + 16
+
+// MemoStats holds statistics of the memo table of a reader
+type MemoStats struct {
+	Entries int
+	Hits    int
+	Misses  int
+	// Bytes is an estimate of the memory held by the memo table, it does not include the
+	// memory of the results referenced by the memoized match results
+	Bytes int
+}
+
+// SetMemoization enables or disables packrat memoization. When enabled the results of Concatenation,
+// Alternation, Repetition and Exception patterns are stored per input position, so repeated attempts
+// to match the same pattern at the same position (for instance when backtracking) return the stored
+// result without running the pattern and its transform again. Disabling memoization clears the table
+func (r *Reader) SetMemoization(enabled bool) {
+	if enabled {
+		if r.memo == nil {
+			r.memo = map[memoKey]*memoEntry{}
+		}
+	} else {
+		r.memo = nil
+	}
+
+	r.memoHits = 0
+	r.memoMisses = 0
+}
+
+// MemoStats returns the statistics of the memo table
+func (r *Reader) MemoStats() MemoStats {
+	return MemoStats{
+		Entries: len(r.memo),
+		Hits:    r.memoHits,
+		Misses:  r.memoMisses,
+		Bytes:   len(r.memo) * memoEntrySize,
+	}
+}
+
+// memoize returns the memoized result of pattern p at the current position or calls match and stores
+// its result. Results are copied in and out of the table so transforms of enclosing patterns which
+// modify a returned result do not change the stored result
+func (r *Reader) memoize(p Pattern, match func(r *Reader) (*MatchResult, error)) (*MatchResult, error) {
+	if r.memo == nil {
+		return match(r)
+	}
+
+	key := memoKey{pattern: p, grammar: r.grammar, pos: r.bufPos}
+
+	if entry, ok := r.memo[key]; ok {
+		r.memoHits++
+		r.seek(entry.end)
+
+		result := *entry.result
+
+		return &result, nil
+	}
+
+	r.memoMisses++
+
+	result, err := match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.leftRecursionHead != nil {
+		return result, nil
+	}
+
+	stored := *result
+
+	r.memo[key] = &memoEntry{
+		result: &stored,
+		end:    r.CurrentPosition(),
+	}
+
+	return result, nil
+}
@@ -0,0 +1,92 @@
+package ebnf
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMemoizationJSON(t *testing.T) {
+	input, err := ioutil.ReadFile("test.json")
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	var values [2]interface{}
+
+	for i, memoize := range []bool{false, true} {
+		reader, err := NewReader(strings.NewReader(string(input)))
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		reader.SetMemoization(memoize)
+
+		result, err := jsonGrammar().Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		if !result.Match {
+			t.Fatalf("expected test.json to match")
+		}
+
+		values[i] = result.Result.([]*MatchResult)[0].Result
+
+		stats := reader.MemoStats()
+		if memoize && (stats.Entries == 0 || stats.Bytes == 0) {
+			t.Errorf("expected memo table to be filled, got %+v", stats)
+		} else if !memoize && stats.Entries != 0 {
+			t.Errorf("expected empty memo table, got %+v", stats)
+		}
+	}
+
+	if !reflect.DeepEqual(values[0], values[1]) {
+		t.Errorf("expected memoized result to equal result without memoization")
+	}
+}
+
+func TestMemoizationBacktracking(t *testing.T) {
+	// Without memoization every nesting level tries the nested expression three times
+	expression := Ref("expression")
+	nested := NewAlternation(
+		[]Pattern{
+			NewConcatenation([]Pattern{NewTerminalString("(", nil), expression, NewTerminalString(")", nil)}, nil),
+			NewTerminalString("1", nil),
+		},
+		nil,
+	)
+
+	grammar := NewGrammar().Rule("expression", NewAlternation(
+		[]Pattern{
+			NewConcatenation([]Pattern{nested, NewTerminalString("+", nil)}, nil),
+			NewConcatenation([]Pattern{nested, NewTerminalString("-", nil)}, nil),
+			nested,
+		},
+		nil,
+	))
+
+	reader, _ := NewReader(strings.NewReader(strings.Repeat("(", 40) + "1" + strings.Repeat(")", 40)))
+	reader.SetMemoization(true)
+
+	result, err := grammar.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() {
+		t.Fatalf("expected expression to match all input")
+	}
+
+	stats := reader.MemoStats()
+	if stats.Hits == 0 || stats.Misses > 1000 {
+		t.Errorf("unexpected memo stats %+v", stats)
+	}
+
+	reader.SetMemoization(false)
+
+	if stats = reader.MemoStats(); stats.Entries != 0 {
+		t.Errorf("expected memo table to be cleared, got %+v", stats)
+	}
+}
@@ -0,0 +1,184 @@
+package ebnf
+
+// NodeKind identifies the kind of pattern that produced a syntax tree node
+type NodeKind int
+
+const (
+	// TerminalNode is produced by TerminalString, LiteralSet and Regexp patterns
+	TerminalNode NodeKind = iota
+	// CharacterNode is produced by CharacterGroup patterns
+	CharacterNode
+	// SequenceNode is produced by Concatenation patterns
+	SequenceNode
+	// RepetitionNode is produced by Repetition patterns
+	RepetitionNode
+	// RuleNode is produced by references to grammar rules
+	RuleNode
+	// OperatorNode is produced by operator applications of an OperatorTable
+	OperatorNode
+	// ErrorNode is produced by a Recovery pattern for skipped input
+	ErrorNode
+	// TriviaNode is produced by Trivia patterns
+	TriviaNode
+)
+
+// String returns the name of a node kind
+func (k NodeKind) String() string {
+	switch k {
+	case TerminalNode:
+		return "terminal"
+	case CharacterNode:
+		return "character"
+	case SequenceNode:
+		return "sequence"
+	case RepetitionNode:
+		return "repetition"
+	case RuleNode:
+		return "rule"
+	case OperatorNode:
+		return "operator"
+	case ErrorNode:
+		return "error"
+	case TriviaNode:
+		return "trivia"
+	}
+
+	return "unknown"
+}
+
+// Node is a node of a concrete syntax tree, it is built while matching when the syntax tree is
+// enabled on the reader (see Reader.SetSyntaxTree). An alternation and an exception result in the
+// node of the matched pattern, lookaheads and end of file patterns do not result in a node. Rule is
+// set for rule nodes, the children of a rule node are the children of the sequence of the rule.
+// Leading and Trailing hold the trivia attached to a token (see Node.AttachTrivia)
+type Node struct {
+	Kind     NodeKind
+	Rule     string
+	Span     Span
+	Children []*Node
+	Leading  []*Node
+	Trailing []*Node
+	reader   *Reader
+}
+
+// SetSyntaxTree enables or disables building a concrete syntax tree, when enabled MatchResult.Node
+// holds the syntax tree node of a successful match
+func (r *Reader) SetSyntaxTree(enabled bool) {
+	r.syntaxTree = enabled
+}
+
+// setNode sets the syntax tree node of a successful match result with the nodes of the child results
+func (r *Reader) setNode(kind NodeKind, m *MatchResult, children []*MatchResult) {
+	if !r.syntaxTree {
+		return
+	}
+
+	nodes := []*Node{}
+
+	for _, child := range children {
+		if child.Node != nil {
+			nodes = append(nodes, child.Node)
+		}
+	}
+
+	m.Node = &Node{
+		Kind:     kind,
+		Span:     m.Span(),
+		Children: nodes,
+		reader:   r,
+	}
+}
+
+// setRuleNode wraps the node of a rule match result in a rule node
+func (r *Reader) setRuleNode(name string, m *MatchResult) {
+	if !r.syntaxTree || !m.Match {
+		return
+	}
+
+	// A rule that matches trivia results in a trivia node
+	if m.Node != nil && m.Node.Kind == TriviaNode {
+		trivia := *m.Node
+		trivia.Rule = name
+		m.Node = &trivia
+		return
+	}
+
+	children := []*Node{}
+
+	if m.Node != nil {
+		if m.Node.Kind == SequenceNode {
+			children = m.Node.Children
+		} else {
+			children = []*Node{m.Node}
+		}
+	}
+
+	m.Node = &Node{
+		Kind:     RuleNode,
+		Rule:     name,
+		Span:     m.Span(),
+		Children: children,
+		reader:   r,
+	}
+}
+
+// Child returns the child node at index i, returns nil if there is no such child
+func (n *Node) Child(i int) *Node {
+	if n == nil || i < 0 || i >= len(n.Children) {
+		return nil
+	}
+
+	return n.Children[i]
+}
+
+// ChildByRule returns the first descendant rule node with the name, rule nodes of other rules are
+// not searched. Returns nil if there is no such node
+func (n *Node) ChildByRule(name string) *Node {
+	nodes := n.ChildrenByRule(name)
+	if len(nodes) == 0 {
+		return nil
+	}
+
+	return nodes[0]
+}
+
+// ChildrenByRule returns the descendant rule nodes with the name in order, rule nodes of other rules
+// are not searched
+func (n *Node) ChildrenByRule(name string) []*Node {
+	nodes := []*Node{}
+
+	if n == nil {
+		return nodes
+	}
+
+	for _, child := range n.Children {
+		if child.Kind == RuleNode {
+			if child.Rule == name {
+				nodes = append(nodes, child)
+			}
+
+			continue
+		}
+
+		nodes = append(nodes, child.ChildrenByRule(name)...)
+	}
+
+	return nodes
+}
+
+// Text returns the input matched by the node, returns an empty string if the input has been discarded
+// by a streaming reader
+func (n *Node) Text() string {
+	if n == nil {
+		return ""
+	}
+
+	begin, end := n.Span.Begin, n.Span.End
+
+	text, err := n.reader.src.text(begin.absoluteCharPos, begin.byteOffset, end.absoluteCharPos, end.byteOffset)
+	if err != nil {
+		return ""
+	}
+
+	return text
+}
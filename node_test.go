@@ -0,0 +1,75 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSyntaxTree(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		program = { assignment } ;
+		assignment = identifier, "=", value, ";" ;
+		value = number | identifier ;
+		identifier = letter, { letter } ;
+		number = digit, { digit } ;
+		letter = "a" | "b" | "c" | "x" ;
+		digit = "0" | "1" | "2" ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	reader, _ := NewReader(strings.NewReader("abc=12;x=a;"))
+	reader.SetSyntaxTree(true)
+
+	result, err := Parse(grammar, reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	root := result.Node
+	if root == nil || root.Kind != RuleNode || root.Rule != "program" || root.Text() != "abc=12;x=a;" {
+		t.Fatalf("unexpected root node %+v", root)
+	}
+
+	assignments := root.ChildrenByRule("assignment")
+	if len(assignments) != 2 {
+		t.Fatalf("expected 2 assignments, got %d", len(assignments))
+	}
+
+	first := assignments[0]
+	if first.Child(0).Rule != "identifier" || first.Child(1).Kind != TerminalNode || first.Child(3).Text() != ";" {
+		t.Errorf("unexpected children of assignment %v", first.Children)
+	}
+
+	if text := first.ChildByRule("value").ChildByRule("number").Text(); text != "12" {
+		t.Errorf("unexpected number %q", text)
+	}
+
+	// Rule nodes of other rules are not searched
+	if first.ChildByRule("digit") != nil {
+		t.Errorf("expected digit to be found in number only")
+	}
+
+	value := assignments[1].ChildByRule("value")
+	if value.ChildByRule("identifier").Text() != "a" || value.Span.String() != "1:10-1:11" {
+		t.Errorf("unexpected value node %v", value.Span)
+	}
+
+	if first.Child(4) != nil || first.ChildByRule("missing").Text() != "" {
+		t.Errorf("expected nil for missing children")
+	}
+}
+
+func TestSyntaxTreeDisabled(t *testing.T) {
+	reader, _ := NewReader(strings.NewReader("ab"))
+
+	result, err := NewConcatenation([]Pattern{NewTerminalString("a", nil), NewTerminalString("b", nil)}, nil).Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || result.Node != nil {
+		t.Errorf("expected no syntax tree")
+	}
+}
@@ -0,0 +1,286 @@
+package ebnf
+
+// Associativity of infix operators
+type Associativity int
+
+const (
+	// LeftAssociative operators group from the left, a - b - c is (a - b) - c
+	LeftAssociative Associativity = iota
+	// RightAssociative operators group from the right, a ^ b ^ c is a ^ (b ^ c)
+	RightAssociative
+	// NonAssociative operators can not be chained, a < b < c only matches a < b
+	NonAssociative
+)
+
+// OperatorKind determines the position of an operator relative to its operands
+type OperatorKind int
+
+const (
+	// Infix operators are placed between two operands
+	Infix OperatorKind = iota
+	// Prefix operators are placed before the operand
+	Prefix
+	// Postfix operators are placed after the operand
+	Postfix
+)
+
+// Operator holds the pattern to match an operator, the transform is called with the match result
+// of the operator application
+type Operator struct {
+	BaseTransformer
+	Pattern Pattern
+}
+
+// NewOperator creates a new operator
+func NewOperator(p Pattern, t TransformFunction) *Operator {
+	return &Operator{
+		BaseTransformer: BaseTransformer{
+			T: t,
+		},
+		Pattern: p,
+	}
+}
+
+// OperatorLevel holds operators of the same kind and precedence
+type OperatorLevel struct {
+	Kind          OperatorKind
+	Associativity Associativity
+	Operators     []*Operator
+}
+
+// NewInfixLevel creates a new level of infix operators
+func NewInfixLevel(associativity Associativity, operators ...*Operator) OperatorLevel {
+	return OperatorLevel{
+		Kind:          Infix,
+		Associativity: associativity,
+		Operators:     operators,
+	}
+}
+
+// NewPrefixLevel creates a new level of prefix operators
+func NewPrefixLevel(operators ...*Operator) OperatorLevel {
+	return OperatorLevel{
+		Kind:      Prefix,
+		Operators: operators,
+	}
+}
+
+// NewPostfixLevel creates a new level of postfix operators
+func NewPostfixLevel(operators ...*Operator) OperatorLevel {
+	return OperatorLevel{
+		Kind:      Postfix,
+		Operators: operators,
+	}
+}
+
+// OperatorTable pattern, matches expressions of operands and prefix, infix and postfix operators
+// using precedence climbing
+type OperatorTable struct {
+	BaseTransformer
+	Operand Pattern
+	Levels  []OperatorLevel
+}
+
+// NewOperatorTable creates a new operator table, levels are ordered from lowest to highest precedence
+func NewOperatorTable(operand Pattern, levels ...OperatorLevel) *OperatorTable {
+	return &OperatorTable{
+		Operand: operand,
+		Levels:  levels,
+	}
+}
+
+// Match operator table pattern. A single operand results in the operand match result, each operator
+// application results in a match result with MatchResult.Result containing []*MatchResult with
+// the operand and operator results: [left, operator, right] for infix, [operator, operand] for prefix
+// and [operand, operator] for postfix operators. The transform of the operator is called for the
+// application, the transform of the table is called for the complete expression
+func (t *OperatorTable) Match(r *Reader) (*MatchResult, error) {
+	return r.invoke(t, t.match)
+}
+
+func (t *OperatorTable) match(r *Reader) (*MatchResult, error) {
+	beginPos := r.CurrentPosition()
+
+	result, err := t.parse(r, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if result == nil {
+		result = &MatchResult{
+			BeginPos: beginPos,
+			EndPos:   beginPos,
+			Match:    false,
+		}
+	}
+
+	err = t.Transform(result, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// matchOperator matches the first operator of the given kind with a precedence of at least
+// minPrecedence, higher precedence levels are tried first. The precedence of a level is its index
+func (t *OperatorTable) matchOperator(r *Reader, kind OperatorKind, minPrecedence int) (*Operator, int, *MatchResult, error) {
+	for precedence := len(t.Levels) - 1; precedence >= minPrecedence; precedence-- {
+		level := &t.Levels[precedence]
+		if level.Kind != kind {
+			continue
+		}
+
+		for _, op := range level.Operators {
+			result, err := op.Pattern.Match(r)
+			if err != nil {
+				return nil, 0, nil, err
+			}
+
+			if result.Match {
+				return op, precedence, result, nil
+			}
+		}
+	}
+
+	return nil, 0, nil, nil
+}
+
+// apply creates the match result of an operator application
+func (t *OperatorTable) apply(r *Reader, op *Operator, operands ...*MatchResult) (*MatchResult, error) {
+	result := &MatchResult{
+		BeginPos: operands[0].BeginPos,
+		EndPos:   r.CurrentPosition(),
+		Match:    true,
+		Result:   operands,
+	}
+
+	r.setNode(OperatorNode, result, operands)
+
+	err := op.Transform(result, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// parse an expression containing operators with a precedence of at least minPrecedence, returns nil
+// and leaves the reader untouched if no expression could be matched
+func (t *OperatorTable) parse(r *Reader, minPrecedence int) (*MatchResult, error) {
+	r.PushState()
+
+	left, err := t.parsePrefix(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if left == nil {
+		r.RestoreState()
+		return nil, nil
+	}
+
+	nonAssociative := -1
+
+	for {
+		op, precedence, opResult, err := t.matchOperator(r, Postfix, minPrecedence)
+		if err != nil {
+			return nil, err
+		}
+
+		if op != nil {
+			left, err = t.apply(r, op, left, opResult)
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		r.PushState()
+
+		op, precedence, opResult, err = t.matchOperator(r, Infix, minPrecedence)
+		if err != nil {
+			return nil, err
+		}
+
+		if op == nil || precedence == nonAssociative {
+			r.RestoreState()
+			break
+		}
+
+		associativity := t.Levels[precedence].Associativity
+
+		rightPrecedence := precedence + 1
+		if associativity == RightAssociative {
+			rightPrecedence = precedence
+		}
+
+		right, err := t.parse(r, rightPrecedence)
+		if err != nil {
+			return nil, err
+		}
+
+		if right == nil {
+			r.RestoreState()
+			break
+		}
+
+		r.PopState()
+
+		left, err = t.apply(r, op, left, opResult, right)
+		if err != nil {
+			return nil, err
+		}
+
+		nonAssociative = -1
+		if associativity == NonAssociative {
+			nonAssociative = precedence
+		}
+	}
+
+	r.PopState()
+
+	return left, nil
+}
+
+// parsePrefix parses an operand preceded by any number of prefix operators, returns nil if no
+// operand could be matched
+func (t *OperatorTable) parsePrefix(r *Reader) (*MatchResult, error) {
+	r.PushState()
+
+	op, precedence, opResult, err := t.matchOperator(r, Prefix, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if op != nil {
+		// The operand of a prefix operator binds operators with a higher precedence
+		operand, err := t.parse(r, precedence)
+		if err != nil {
+			return nil, err
+		}
+
+		if operand != nil {
+			r.PopState()
+			return t.apply(r, op, opResult, operand)
+		}
+
+		r.RestoreState()
+		r.PushState()
+	}
+
+	result, err := t.Operand.Match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !result.Match {
+		r.RestoreState()
+		return nil, nil
+	}
+
+	r.PopState()
+
+	return result, nil
+}
@@ -0,0 +1,86 @@
+package ebnf
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// operatorTreeTransform prints the operator application as an s-expression
+func operatorTreeTransform(m *MatchResult, r *Reader) error {
+	if !m.Match {
+		return nil
+	}
+
+	parts := []string{}
+	for _, operand := range m.Result.([]*MatchResult) {
+		parts = append(parts, fmt.Sprintf("%v", operand.Result))
+	}
+
+	m.Result = "(" + strings.Join(parts, " ") + ")"
+
+	return nil
+}
+
+func operatorTable() Pattern {
+	operator := func(s string) *Operator {
+		return NewOperator(NewTerminalString(s, nil), operatorTreeTransform)
+	}
+
+	return NewOperatorTable(
+		NewCharacterRange('a', 'z', false, nil),
+		NewInfixLevel(NonAssociative, operator("<"), operator("=")),
+		NewInfixLevel(LeftAssociative, operator("+"), operator("-")),
+		NewInfixLevel(LeftAssociative, operator("*"), operator("/")),
+		NewPrefixLevel(operator("-")),
+		NewInfixLevel(RightAssociative, operator("^")),
+		NewPostfixLevel(operator("!")),
+	)
+}
+
+func TestOperatorTable(t *testing.T) {
+	inputs := map[string]string{
+		"a":         "a",
+		"a+b*c":     "(a + (b * c))",
+		"a-b-c":     "((a - b) - c)",
+		"a^b^c":     "(a ^ (b ^ c))",
+		"-a^b":      "(- (a ^ b))",
+		"-a*b":      "((- a) * b)",
+		"a*-b+c":    "((a * (- b)) + c)",
+		"a!^b":      "((a !) ^ b)",
+		"--a!":      "(- (- (a !)))",
+		"a+b<c*d":   "((a + b) < (c * d))",
+		"a<b<c":     "(a < b)",
+		"a+":        "a",
+		"a+b=c-d!":  "((a + b) = (c - (d !)))",
+		"a*(b)":     "a",
+		"-":         "",
+		"a^b*c^d-e": "(((a ^ b) * (c ^ d)) - e)",
+	}
+
+	table := operatorTable()
+
+	for input, expected := range inputs {
+		reader, _ := NewReader(strings.NewReader(input))
+
+		result, err := table.Match(reader)
+		if err != nil {
+			t.Fatalf("err %v", err)
+		}
+
+		if expected == "" {
+			if result.Match || reader.CurrentPosition().Offset() != 0 {
+				t.Errorf("input %q: expected no match", input)
+			}
+			continue
+		}
+
+		if !result.Match || result.Result != expected {
+			t.Errorf("input %q: expected %v, got %v", input, expected, result.Result)
+		}
+
+		if end := reader.CurrentPosition().Offset(); end != result.EndPos.Offset() {
+			t.Errorf("input %q: reader at %d, expected %d", input, end, result.EndPos.Offset())
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package ebnf
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// Offset returns the zero based rune offset of the position in the input
+func (p *ReaderPos) Offset() int {
+	return p.absoluteCharPos
+}
+
+// ByteOffset returns the zero based byte offset of the position in the UTF-8 encoded input
+func (p *ReaderPos) ByteOffset() int {
+	return p.byteOffset
+}
+
+// Line returns the one based line number of the position
+func (p *ReaderPos) Line() int {
+	return p.linePos + 1
+}
+
+// Column returns the one based column of the position counted in runes
+func (p *ReaderPos) Column() int {
+	return p.relativeCharPos + 1
+}
+
+// linePrefix returns the runes of the line before the position, returns false if the line has been
+// discarded by a streaming reader
+func (p *ReaderPos) linePrefix() ([]rune, bool) {
+	start, ok := p.reader.lineStart(p.linePos)
+	if !ok {
+		return nil, false
+	}
+
+	s, err := p.reader.src.text(start.pos, start.off, p.absoluteCharPos, p.byteOffset)
+	if err != nil {
+		return nil, false
+	}
+
+	return []rune(s), true
+}
+
+// UTF16Column returns the one based column of the position counted in UTF-16 code units, runes
+// outside of the basic multilingual plane count as two units. Returns Column if the line has been
+// discarded by a streaming reader
+func (p *ReaderPos) UTF16Column() int {
+	prefix, ok := p.linePrefix()
+	if !ok {
+		return p.Column()
+	}
+
+	column := 1
+
+	for _, rn := range prefix {
+		if rn >= 0x10000 {
+			column += 2
+		} else {
+			column++
+		}
+	}
+
+	return column
+}
+
+// DisplayColumn returns the one based column of the position as displayed in a terminal, tabs advance
+// to the next multiple of tabWidth, wide east asian characters count as two columns and combining marks
+// do not count. Returns Column if the line has been discarded by a streaming reader
+func (p *ReaderPos) DisplayColumn(tabWidth int) int {
+	prefix, ok := p.linePrefix()
+	if !ok {
+		return p.Column()
+	}
+
+	width := 0
+
+	for _, rn := range prefix {
+		if rn == '\t' && tabWidth > 0 {
+			width += tabWidth - width%tabWidth
+		} else {
+			width += runeWidth(rn)
+		}
+	}
+
+	return width + 1
+}
+
+// String returns the position as line:column
+func (p *ReaderPos) String() string {
+	return fmt.Sprintf("%d:%d", p.Line(), p.Column())
+}
+
+// wideRanges holds the rune ranges that are displayed with double width
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F},
+	{0x2E80, 0x303E},
+	{0x3041, 0x33FF},
+	{0x3400, 0x4DBF},
+	{0x4E00, 0x9FFF},
+	{0xA000, 0xA4CF},
+	{0xAC00, 0xD7A3},
+	{0xF900, 0xFAFF},
+	{0xFE30, 0xFE4F},
+	{0xFF00, 0xFF60},
+	{0xFFE0, 0xFFE6},
+	{0x1F300, 0x1F64F},
+	{0x1F900, 0x1F9FF},
+	{0x20000, 0x3FFFD},
+}
+
+// runeWidth returns the number of columns used to display a rune
+func runeWidth(rn rune) int {
+	if unicode.In(rn, unicode.Mn, unicode.Me, unicode.Cf) {
+		return 0
+	}
+
+	for _, r := range wideRanges {
+		if rn >= r[0] && rn <= r[1] {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// Span holds the begin and end position of a range of input
+type Span struct {
+	Begin *ReaderPos
+	End   *ReaderPos
+}
+
+// Len returns the number of runes in the span
+func (s Span) Len() int {
+	return s.End.absoluteCharPos - s.Begin.absoluteCharPos
+}
+
+// Contains returns true if the position lies within the span, the end position is excluded
+func (s Span) Contains(p *ReaderPos) bool {
+	return p.absoluteCharPos >= s.Begin.absoluteCharPos && p.absoluteCharPos < s.End.absoluteCharPos
+}
+
+// String returns the span as line:column-line:column
+func (s Span) String() string {
+	return fmt.Sprintf("%v-%v", s.Begin, s.End)
+}
+
+// Span returns the span of the match
+func (m *MatchResult) Span() Span {
+	return Span{
+		Begin: m.BeginPos,
+		End:   m.EndPos,
+	}
+}
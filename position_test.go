@@ -0,0 +1,72 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReaderPos(t *testing.T) {
+	reader, _ := NewReader(strings.NewReader("ab\r\n\t😃世x = 1"))
+
+	pattern := NewConcatenation(
+		[]Pattern{
+			NewTerminalString("ab\r\n\t😃世", nil),
+			NewTerminalString("x", nil),
+		},
+		nil,
+	)
+
+	result, err := pattern.Match(reader)
+	if err != nil || !result.Match {
+		t.Fatalf("expected match")
+	}
+
+	pos := result.Result.([]*MatchResult)[1].BeginPos
+
+	if pos.Offset() != 7 {
+		t.Errorf("expected offset 7, got %d", pos.Offset())
+	}
+
+	if pos.ByteOffset() != 2+2+1+4+3 {
+		t.Errorf("expected byte offset 12, got %d", pos.ByteOffset())
+	}
+
+	if pos.Line() != 2 || pos.Column() != 4 {
+		t.Errorf("expected line 2, column 4, got %v", pos)
+	}
+
+	if pos.UTF16Column() != 5 {
+		t.Errorf("expected UTF-16 column 5, got %d", pos.UTF16Column())
+	}
+
+	if pos.DisplayColumn(4) != 9 {
+		t.Errorf("expected display column 9, got %d", pos.DisplayColumn(4))
+	}
+
+	span := result.Result.([]*MatchResult)[1].Span()
+	if span.Len() != 1 || !span.Contains(pos) || span.Contains(span.End) || span.String() != "2:4-2:5" {
+		t.Errorf("unexpected span %v", span)
+	}
+
+	// Byte offsets are restored on backtracking
+	reader, _ = NewReader(strings.NewReader("😃😃b"))
+
+	pattern = NewConcatenation(
+		[]Pattern{
+			NewAlternation(
+				[]Pattern{
+					NewConcatenation([]Pattern{NewTerminalString("😃😃", nil), NewTerminalString("a", nil)}, nil),
+					NewTerminalString("😃", nil),
+				},
+				nil,
+			),
+			NewTerminalString("😃", nil),
+		},
+		nil,
+	)
+
+	result, err = pattern.Match(reader)
+	if err != nil || !result.Match || result.EndPos.ByteOffset() != 8 {
+		t.Errorf("expected match ending at byte offset 8")
+	}
+}
@@ -0,0 +1,589 @@
+package ebnf
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// queryTree is an element of a match result tree or a syntax tree that is searched by a query
+type queryTree interface {
+	key() interface{}
+	rule() string
+	text() string
+	children() []queryTree
+}
+
+// resultTree adapts a match result to a query tree
+type resultTree struct {
+	m *MatchResult
+}
+
+func (t resultTree) key() interface{} {
+	return t.m
+}
+
+func (t resultTree) rule() string {
+	return t.m.Rule
+}
+
+func (t resultTree) text() string {
+	if t.m.BeginPos == nil || t.m.EndPos == nil || t.m.BeginPos.reader == nil {
+		return ""
+	}
+
+	begin, end := t.m.BeginPos, t.m.EndPos
+
+	text, err := begin.reader.src.text(begin.absoluteCharPos, begin.byteOffset, end.absoluteCharPos, end.byteOffset)
+	if err != nil {
+		return ""
+	}
+
+	return text
+}
+
+func (t resultTree) children() []queryTree {
+	trees := []queryTree{}
+
+	for _, child := range children(t.m) {
+		if child != nil {
+			trees = append(trees, resultTree{m: child})
+		}
+	}
+
+	return trees
+}
+
+// nodeTree adapts a syntax tree node to a query tree
+type nodeTree struct {
+	n *Node
+}
+
+func (t nodeTree) key() interface{} {
+	return t.n
+}
+
+func (t nodeTree) rule() string {
+	return t.n.Rule
+}
+
+func (t nodeTree) text() string {
+	return t.n.Text()
+}
+
+func (t nodeTree) children() []queryTree {
+	trees := []queryTree{}
+
+	for _, child := range t.n.Children {
+		trees = append(trees, nodeTree{n: child})
+	}
+
+	return trees
+}
+
+// queryCombinator relates a compound selector to the previous one
+type queryCombinator int
+
+const (
+	descendantCombinator queryCombinator = iota
+	childCombinator
+	selfOrDescendantCombinator
+)
+
+// queryPredicate tests the text of an element
+type queryPredicate struct {
+	op    string
+	value string
+}
+
+// queryCompound selects elements by rule name, text predicates, :has and :not, and captures them
+type queryCompound struct {
+	name       string
+	predicates []queryPredicate
+	has        [][]queryStep
+	not        []*queryCompound
+	capture    string
+}
+
+// queryStep is a compound selector with the combinator that relates it to the previous step
+type queryStep struct {
+	combinator queryCombinator
+	compound   *queryCompound
+}
+
+// Query selects the rule elements of match result trees or syntax trees, elements without a rule name
+// are only traversed, so the children of an element are its closest descendants with a rule name.
+// Queries are written like CSS selectors with rule names as types:
+//
+//	assignment > value > string      string rules that are a child of a value that is a child of an assignment
+//	list number                      number rules that are a descendant of a list
+//	*[text="nil"]                    rules that matched the text nil, the operators ^= (prefix), $= (suffix)
+//	                                 and *= (contains) are also supported
+//	assignment:has(> value > string) assignments with a string value
+//	value:not(number)                values which are not a number
+//	assignment > identifier@name     identifiers of assignments, captured as name
+//	number, string                   number or string rules
+//
+// Rule names which are not identifiers are quoted: "white space". Captures hold the element selected
+// by the compound selector they are attached to
+type Query struct {
+	selectors [][]queryStep
+	names     []string
+}
+
+// QueryMatch holds an element of a syntax tree selected by a query and the captured nodes
+type QueryMatch struct {
+	Node     *Node
+	Captures map[string]*Node
+}
+
+// QueryResult holds an element of a match result tree selected by a query and the captured results
+type QueryResult struct {
+	Result   *MatchResult
+	Captures map[string]*MatchResult
+}
+
+// queryParser parses a query
+type queryParser struct {
+	input []rune
+	pos   int
+	names []string
+}
+
+// CompileQuery compiles a query
+func CompileQuery(query string) (*Query, error) {
+	p := &queryParser{input: []rune(query)}
+
+	q := &Query{}
+
+	for {
+		steps, err := p.selector(selfOrDescendantCombinator)
+		if err != nil {
+			return nil, err
+		}
+
+		q.selectors = append(q.selectors, steps)
+
+		p.skipSpace()
+
+		if p.pos == len(p.input) {
+			break
+		}
+
+		if !p.accept(",") {
+			return nil, p.errorf("expected \",\"")
+		}
+	}
+
+	q.names = p.names
+
+	return q, nil
+}
+
+// Query compiles a query for the grammar, the rule names in the query must be defined by the grammar
+func (g *Grammar) Query(query string) (*Query, error) {
+	q, err := CompileQuery(query)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range q.names {
+		if _, ok := g.rules[name]; !ok {
+			return nil, fmt.Errorf("query references undefined rule %q", name)
+		}
+	}
+
+	return q, nil
+}
+
+// errorf creates an error at the current position of the parser
+func (p *queryParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s at query pos %d", fmt.Sprintf(format, args...), p.pos+1)
+}
+
+func (p *queryParser) skipSpace() bool {
+	skipped := false
+
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+		skipped = true
+	}
+
+	return skipped
+}
+
+func (p *queryParser) peek(s string) bool {
+	return strings.HasPrefix(string(p.input[p.pos:]), s)
+}
+
+func (p *queryParser) accept(s string) bool {
+	if p.peek(s) {
+		p.pos += len([]rune(s))
+		return true
+	}
+
+	return false
+}
+
+// selector parses compound selectors separated by combinators, the first step has the given combinator
+// unless it starts with >
+func (p *queryParser) selector(first queryCombinator) ([]queryStep, error) {
+	steps := []queryStep{}
+	combinator := first
+
+	p.skipSpace()
+
+	if p.accept(">") {
+		combinator = childCombinator
+		p.skipSpace()
+	}
+
+	for {
+		compound, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+
+		steps = append(steps, queryStep{combinator: combinator, compound: compound})
+
+		space := p.skipSpace()
+
+		switch {
+		case p.accept(">"):
+			combinator = childCombinator
+			p.skipSpace()
+		case space && p.pos < len(p.input) && !p.peek(",") && !p.peek(")"):
+			combinator = descendantCombinator
+		default:
+			return steps, nil
+		}
+	}
+}
+
+func (p *queryParser) identifier() string {
+	begin := p.pos
+
+	for p.pos < len(p.input) {
+		rn := p.input[p.pos]
+		if !unicode.IsLetter(rn) && !unicode.IsDigit(rn) && rn != '_' && rn != '-' {
+			break
+		}
+
+		p.pos++
+	}
+
+	return string(p.input[begin:p.pos])
+}
+
+func (p *queryParser) quoted() (string, error) {
+	begin := p.pos
+	p.pos++
+
+	for p.pos < len(p.input) && p.input[p.pos] != '"' {
+		if p.input[p.pos] == '\\' {
+			p.pos++
+		}
+
+		p.pos++
+	}
+
+	if p.pos >= len(p.input) {
+		p.pos = begin
+		return "", p.errorf("unterminated string")
+	}
+
+	p.pos++
+
+	s, err := strconv.Unquote(string(p.input[begin:p.pos]))
+	if err != nil {
+		p.pos = begin
+		return "", p.errorf("invalid string")
+	}
+
+	return s, nil
+}
+
+// compound parses a compound selector
+func (p *queryParser) compound() (*queryCompound, error) {
+	c := &queryCompound{}
+
+	switch {
+	case p.accept("*"):
+	case p.peek(`"`):
+		name, err := p.quoted()
+		if err != nil {
+			return nil, err
+		}
+
+		c.name = name
+	default:
+		c.name = p.identifier()
+		if c.name == "" {
+			return nil, p.errorf("expected rule name")
+		}
+	}
+
+	if c.name != "" {
+		p.names = append(p.names, c.name)
+	}
+
+	for {
+		switch {
+		case p.accept("[text"):
+			op := ""
+
+			for _, candidate := range []string{"^=", "$=", "*=", "="} {
+				if p.accept(candidate) {
+					op = candidate
+					break
+				}
+			}
+
+			if op == "" || !p.peek(`"`) {
+				return nil, p.errorf("expected text predicate")
+			}
+
+			value, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+
+			if !p.accept("]") {
+				return nil, p.errorf("expected \"]\"")
+			}
+
+			c.predicates = append(c.predicates, queryPredicate{op: op, value: value})
+		case p.accept(":has("):
+			steps, err := p.selector(descendantCombinator)
+			if err != nil {
+				return nil, err
+			}
+
+			if !p.accept(")") {
+				return nil, p.errorf("expected \")\"")
+			}
+
+			c.has = append(c.has, steps)
+		case p.accept(":not("):
+			p.skipSpace()
+
+			not, err := p.compound()
+			if err != nil {
+				return nil, err
+			}
+
+			p.skipSpace()
+
+			if !p.accept(")") {
+				return nil, p.errorf("expected \")\"")
+			}
+
+			c.not = append(c.not, not)
+		case p.accept("@"):
+			c.capture = p.identifier()
+			if c.capture == "" {
+				return nil, p.errorf("expected capture name")
+			}
+
+			return c, nil
+		default:
+			return c, nil
+		}
+	}
+}
+
+// queryState is an element reached while evaluating a query together with the captures on the way
+type queryState struct {
+	tree     queryTree
+	captures map[string]queryTree
+}
+
+// matches returns true if the compound selector matches an element
+func (c *queryCompound) matches(t queryTree) bool {
+	if t.rule() == "" || (c.name != "" && t.rule() != c.name) {
+		return false
+	}
+
+	for _, predicate := range c.predicates {
+		text := t.text()
+
+		var ok bool
+
+		switch predicate.op {
+		case "=":
+			ok = text == predicate.value
+		case "^=":
+			ok = strings.HasPrefix(text, predicate.value)
+		case "$=":
+			ok = strings.HasSuffix(text, predicate.value)
+		case "*=":
+			ok = strings.Contains(text, predicate.value)
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	for _, not := range c.not {
+		if not.matches(t) {
+			return false
+		}
+	}
+
+	for _, has := range c.has {
+		if len(evaluateQuery([]queryState{{tree: t}}, has)) == 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ruleChildren returns the closest descendants of an element with a rule name in pre order, elements
+// without a rule name are traversed
+func ruleChildren(t queryTree, trees []queryTree) []queryTree {
+	for _, child := range t.children() {
+		if child.rule() != "" {
+			trees = append(trees, child)
+		} else {
+			trees = ruleChildren(child, trees)
+		}
+	}
+
+	return trees
+}
+
+// descendants returns the descendants of an element in pre order
+func descendants(t queryTree, trees []queryTree) []queryTree {
+	for _, child := range t.children() {
+		trees = append(trees, child)
+		trees = descendants(child, trees)
+	}
+
+	return trees
+}
+
+// evaluateQuery evaluates the steps of a selector from the given states
+func evaluateQuery(states []queryState, steps []queryStep) []queryState {
+	for _, step := range steps {
+		next := []queryState{}
+
+		for _, state := range states {
+			var candidates []queryTree
+
+			switch step.combinator {
+			case childCombinator:
+				candidates = ruleChildren(state.tree, nil)
+			case descendantCombinator:
+				candidates = descendants(state.tree, nil)
+			case selfOrDescendantCombinator:
+				candidates = descendants(state.tree, []queryTree{state.tree})
+			}
+
+			for _, candidate := range candidates {
+				if !step.compound.matches(candidate) {
+					continue
+				}
+
+				captures := state.captures
+
+				if step.compound.capture != "" {
+					captures = map[string]queryTree{}
+
+					for name, captured := range state.captures {
+						captures[name] = captured
+					}
+
+					captures[step.compound.capture] = candidate
+				}
+
+				next = append(next, queryState{tree: candidate, captures: captures})
+			}
+		}
+
+		states = next
+	}
+
+	return states
+}
+
+// evaluate returns the states of the selected elements in pre order, an element selected more than
+// once is returned with the captures of the first selection
+func (q *Query) evaluate(root queryTree) []queryState {
+	order := map[interface{}]int{}
+
+	for i, t := range descendants(root, []queryTree{root}) {
+		if _, ok := order[t.key()]; !ok {
+			order[t.key()] = i
+		}
+	}
+
+	selected := map[interface{}]bool{}
+	states := []queryState{}
+
+	for _, steps := range q.selectors {
+		for _, state := range evaluateQuery([]queryState{{tree: root}}, steps) {
+			if !selected[state.tree.key()] {
+				selected[state.tree.key()] = true
+				states = append(states, state)
+			}
+		}
+	}
+
+	sort.SliceStable(states, func(i, j int) bool {
+		return order[states[i].tree.key()] < order[states[j].tree.key()]
+	})
+
+	return states
+}
+
+// Nodes returns the nodes of a syntax tree selected by the query in pre order
+func (q *Query) Nodes(root *Node) []*QueryMatch {
+	matches := []*QueryMatch{}
+
+	if root == nil {
+		return matches
+	}
+
+	for _, state := range q.evaluate(nodeTree{n: root}) {
+		match := &QueryMatch{
+			Node:     state.tree.(nodeTree).n,
+			Captures: map[string]*Node{},
+		}
+
+		for name, captured := range state.captures {
+			match.Captures[name] = captured.(nodeTree).n
+		}
+
+		matches = append(matches, match)
+	}
+
+	return matches
+}
+
+// Results returns the match results of a match result tree selected by the query in pre order
+func (q *Query) Results(root *MatchResult) []*QueryResult {
+	results := []*QueryResult{}
+
+	if root == nil {
+		return results
+	}
+
+	for _, state := range q.evaluate(resultTree{m: root}) {
+		result := &QueryResult{
+			Result:   state.tree.(resultTree).m,
+			Captures: map[string]*MatchResult{},
+		}
+
+		for name, captured := range state.captures {
+			result.Captures[name] = captured.(resultTree).m
+		}
+
+		results = append(results, result)
+	}
+
+	return results
+}
@@ -0,0 +1,129 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func queryGrammar(t *testing.T) *Grammar {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		program = { assignment } ;
+		assignment = identifier, "=", value, ";" ;
+		value = string | number | list ;
+		list = "(", value, { ",", value }, ")" ;
+		string = '"', { letter }, '"' ;
+		identifier = letter, { letter } ;
+		number = digit, { digit } ;
+		letter = "a" | "b" | "c" | "x" | "y" ;
+		digit = "0" | "1" | "2" ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	return grammar
+}
+
+func TestQueryNodes(t *testing.T) {
+	grammar := queryGrammar(t)
+
+	reader, _ := NewReader(strings.NewReader(`a="abc";b=12;c=(1,"x",(2));x="";`))
+	reader.SetSyntaxTree(true)
+
+	result, err := Parse(grammar, reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	queries := map[string]string{
+		`assignment:has(> value > string) > identifier`:      "a x",
+		`assignment > value > string`:                        `"abc" ""`,
+		`list string`:                                        `"x"`,
+		`list > value > number, assignment > value > number`: "12 1 2",
+		`value:not(list)[text^="1"]`:                         "12 1",
+		`identifier[text*="b"], string[text*="b"]`:           `"abc" b`,
+		`value > list value:has(list)`:                       "(2)",
+		`assignment > value > list > value`:                  `1 "x" (2)`,
+	}
+
+	for query, expected := range queries {
+		q, err := grammar.Query(query)
+		if err != nil {
+			t.Fatalf("query %q: err %v", query, err)
+		}
+
+		texts := []string{}
+		for _, match := range q.Nodes(result.Node) {
+			texts = append(texts, match.Node.Text())
+		}
+
+		if strings.Join(texts, " ") != expected {
+			t.Errorf("query %q: expected %q, got %q", query, expected, strings.Join(texts, " "))
+		}
+	}
+
+	q, _ := grammar.Query(`assignment:has(> value > number)@assignment > identifier@name`)
+
+	matches := q.Nodes(result.Node)
+	if len(matches) != 1 || matches[0].Captures["name"].Text() != "b" || matches[0].Captures["assignment"].Text() != "b=12;" {
+		t.Errorf("unexpected captures")
+	}
+}
+
+func TestQueryResults(t *testing.T) {
+	grammar := queryGrammar(t)
+
+	reader, _ := NewReader(strings.NewReader(`a=1;b="c";`))
+
+	result, err := Parse(grammar, reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	q, err := CompileQuery(`assignment > identifier@name, "letter"[text="c"]`)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	results := q.Results(result)
+	if len(results) != 3 || results[0].Captures["name"] != results[0].Result || reader.StringFromResult(results[2].Result) != "c" {
+		t.Errorf("unexpected results %v", results)
+	}
+
+	// Values wrap the results of the number and string rules
+	q, _ = grammar.Query(`value > number, value > string`)
+
+	results = q.Results(result)
+	if len(results) != 2 || results[0].Result.Rule != "number" || reader.StringFromResult(results[1].Result) != `"c"` {
+		t.Errorf("unexpected results %v", results)
+	}
+
+	reader, _ = NewReader(strings.NewReader(`a=(1,2,(1));`))
+	result, _ = Parse(grammar, reader)
+
+	q, _ = grammar.Query(`assignment > value > list > value`)
+
+	if results = q.Results(result); len(results) != 3 {
+		t.Errorf("expected 3 values, got %d", len(results))
+	}
+}
+
+func TestQueryErrors(t *testing.T) {
+	grammar := queryGrammar(t)
+
+	queries := map[string]string{
+		`assignment >`:            "expected rule name at query pos 13",
+		`value[text~"x"]`:         "expected text predicate at query pos 11",
+		`value:has(string`:        `expected ")" at query pos 17`,
+		`value@`:                  "expected capture name at query pos 7",
+		`assignment > expression`: `query references undefined rule "expression"`,
+		`"white space`:            "unterminated string at query pos 1",
+	}
+
+	for query, expected := range queries {
+		_, err := grammar.Query(query)
+		if err == nil || err.Error() != expected {
+			t.Errorf("query %q: expected error %q, got %v", query, expected, err)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package ebnf
 
 import (
 	"bufio"
+	"context"
 	"io"
 )
 
@@ -10,19 +11,65 @@ type ReaderPos struct {
 	absoluteCharPos int
 	relativeCharPos int
 	linePos         int
+	byteOffset      int
+	reader          *Reader
 }
 
+// lineStart holds the position of the first rune of a line
+type lineStart struct {
+	pos int
+	off int
+}
+
+// discardChunk is the number of runes a streaming reader reads before discarding input
+const discardChunk = 4096
+
 // Reader buffers runes to allow us to backtrack when the runes do not match a pattern
 type Reader struct {
-	buf          []rune
+	src          source
+	streaming    bool
+	discarded    int
+	discardPos   int
+	err          error
 	bufPos       int
-	bufPosEnd    int
 	bufPosStack  []int
-	lines        []int
+	bytePos      int
+	bytePosStack []int
+	lines        []lineStart
+	lineBase     int
 	linePos      int
-	linePosEnd   int
 	linePosStack []int
+	releaseStack []bool
 	errorStack   []*MatchResult
+	failPos      *ReaderPos
+	expected     []string
+	grammar      *Grammar
+	ctx          context.Context
+	limits       Limits
+	steps        int
+	depth        int
+	syntaxTree   bool
+	// Alternations try all alternatives in order instead of dispatching on the next rune
+	ordered    bool
+	memo       map[memoKey]*memoEntry
+	memoHits   int
+	memoMisses int
+	// Rule invocations in progress, used for left recursion detection
+	ruleCalls         map[ruleKey]*ruleCall
+	leftRecursionHead *ruleCall
+}
+
+// newReader creates a new reader for a source
+func newReader(src source) *Reader {
+	return &Reader{
+		src:          src,
+		bufPosStack:  []int{0},
+		bytePosStack: []int{0},
+		linePosStack: []int{0},
+		releaseStack: []bool{false},
+		lines:        []lineStart{},
+		errorStack:   []*MatchResult{},
+	}
 }
 
 // NewReader creates a new reader, all runes in input reader are first read and buffered
@@ -44,46 +91,87 @@ func NewReader(r io.Reader) (*Reader, error) {
 		rs = append(rs, r)
 	}
 
-	// Prefetch all lines, normalize CRLF sequences to LF
-	lines := []int{}
-	l := len(rs)
-	index := 0
+	return newReader(runeSource(rs)), nil
+}
 
-	for index < l {
-		r := rs[index]
+// NewBytesReader creates a new reader over UTF-8 encoded input, runes are decoded when they are read and
+// BytesFromResult returns slices of the input without copying. The input should not be modified while it
+// is being read
+func NewBytesReader(b []byte) *Reader {
+	return newReader(byteSource(b))
+}
 
-		index++
+// NewStreamingReader creates a new reader which reads runes from the input reader when they are needed.
+// Runes before the oldest pushed state are discarded, so memory is bounded by the backtracking window.
+// Note that every pattern holds a pushed state at its begin position while matching, except for repetitions
+// once their minimum is reached, so input is discarded while matching a top level repetition but not while
+// matching a top level concatenation. Reading a rule with a loop of Match calls also bounds the memory.
+// String and StringFromResult return an empty string for discarded input and the error ErrDiscarded is
+// returned by Err and the transform of the pattern
+func NewStreamingReader(r io.Reader) *Reader {
+	reader := newReader(&streamSource{reader: bufio.NewReader(r)})
+	reader.streaming = true
+	return reader
+}
 
-		if r == '\r' {
-			if index < l && rs[index] == '\n' {
-				index++
-			}
+// lineStart returns the start of a line, returns false if the line start has been discarded
+func (r *Reader) lineStart(line int) (lineStart, bool) {
+	if line == 0 {
+		return lineStart{}, true
+	}
+
+	index := line - 1 - r.lineBase
+	if index < 0 || index >= len(r.lines) {
+		return lineStart{}, false
+	}
 
-			lines = append(lines, index)
-		} else if r == '\n' {
-			lines = append(lines, index)
+	return r.lines[index], true
+}
+
+// discard releases the input before the oldest pushed state of a streaming reader, nothing is done if
+// the oldest pushed state did not move since the last discard
+func (r *Reader) discard() {
+	r.discardPos = r.bufPos
+
+	pos, off, line := r.bufPos, r.bytePos, r.linePos
+
+	for i := 1; i < len(r.bufPosStack); i++ {
+		if !r.releaseStack[i] {
+			pos, off, line = r.bufPosStack[i], r.bytePosStack[i], r.linePosStack[i]
+			break
 		}
 	}
 
-	// Create reader with buffer and lines
-	return &Reader{
-		buf:          rs,
-		bufPosEnd:    len(rs),
-		bufPosStack:  []int{0},
-		linePosStack: []int{0},
-		lines:        lines,
-		linePosEnd:   len(lines),
-		errorStack:   []*MatchResult{},
-	}, nil
+	if pos <= r.discarded {
+		return
+	}
+
+	r.src.discard(pos, off)
+	r.discarded = pos
+
+	if n := line - 1 - r.lineBase; n > 0 {
+		r.lines = append([]lineStart{}, r.lines[n:]...)
+		r.lineBase += n
+	}
+
+	// Memoized results before the discarded position can not be reached anymore
+	for key := range r.memo {
+		if key.pos < pos {
+			delete(r.memo, key)
+		}
+	}
+}
+
+// Err returns the error of String, StringFromResult or BytesFromResult if input was requested that
+// has been discarded
+func (r *Reader) Err() error {
+	return r.err
 }
 
 // Relative position of cursor with regards to line position
 func (r *Reader) relativePosition() int {
-	if r.linePos == 0 {
-		return r.bufPos
-	}
-
-	return r.bufPos - r.lines[r.linePos-1]
+	start, _ := r.lineStart(r.linePos)
+	return r.bufPos - start.pos
 }
 
 // CurrentPosition returns the current reader position
@@ -92,13 +180,30 @@ func (r *Reader) CurrentPosition() *ReaderPos {
 		absoluteCharPos: r.bufPos,
 		relativeCharPos: r.relativePosition(),
 		linePos:         r.linePos,
+		byteOffset:      r.bytePos,
+		reader:          r,
 	}
 }
 
+// seek moves the reader to a position that has been read before
+func (r *Reader) seek(pos *ReaderPos) {
+	r.bufPos = pos.absoluteCharPos
+	r.linePos = pos.linePos
+	r.bytePos = pos.byteOffset
+}
+
 // PushState pushes the current buffer state on the stack
 func (r *Reader) PushState() {
 	r.bufPosStack = append(r.bufPosStack, r.bufPos)
+	r.bytePosStack = append(r.bytePosStack, r.bytePos)
 	r.linePosStack = append(r.linePosStack, r.linePos)
+	r.releaseStack = append(r.releaseStack, false)
+}
+
+// releaseState marks the last pushed buffer state as no longer needed to backtrack, a streaming
+// reader may discard the input after a released state
+func (r *Reader) releaseState() {
+	r.releaseStack[len(r.releaseStack)-1] = true
 }
 
 // RestoreState pops and restores the buffer position to the last pushed buffer position from the stack
@@ -106,8 +211,13 @@ func (r *Reader) RestoreState() {
 	l := len(r.bufPosStack) - 1
 	r.bufPos, r.bufPosStack = r.bufPosStack[l], r.bufPosStack[:l]
 
+	l = len(r.bytePosStack) - 1
+	r.bytePos, r.bytePosStack = r.bytePosStack[l], r.bytePosStack[:l]
+
 	l = len(r.linePosStack) - 1
 	r.linePos, r.linePosStack = r.linePosStack[l], r.linePosStack[:l]
+
+	r.releaseStack = r.releaseStack[:l]
 }
 
 // PopState pops the last pushed buffer state from the stack without restoring
@@ -115,53 +225,99 @@ func (r *Reader) PopState() {
 	l := len(r.bufPosStack) - 1
 	r.bufPosStack = r.bufPosStack[:l]
 
+	l = len(r.bytePosStack) - 1
+	r.bytePosStack = r.bytePosStack[:l]
+
 	l = len(r.linePosStack) - 1
 	r.linePosStack = r.linePosStack[:l]
+
+	r.releaseStack = r.releaseStack[:l]
+}
+
+// text returns the input between two positions, records the error if the input is not available
+func (r *Reader) text(begin int, beginOff int, end int, endOff int) string {
+	s, err := r.src.text(begin, beginOff, end, endOff)
+	if err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+
+		return ""
+	}
+
+	return s
 }
 
 // String gets the current buffer content between the previous pos and the current pos as string
 func (r *Reader) String() string {
-	prevPos := r.bufPosStack[len(r.bufPosStack)-1]
-	return string(r.buf[prevPos:r.bufPos])
+	l := len(r.bufPosStack) - 1
+	return r.text(r.bufPosStack[l], r.bytePosStack[l], r.bufPos, r.bytePos)
 }
 
 // Finished returns true if end of buffer is reached
 func (r *Reader) Finished() bool {
-	return r.bufPos >= r.bufPosEnd
+	_, _, err := r.src.read(r.bufPos, r.bytePos)
+	return err == io.EOF
 }
 
 // Peak returns the next rune without advancing the read position
 func (r *Reader) Peak() (rn rune, err error) {
-	if r.bufPos < r.bufPosEnd {
-		rn = r.buf[r.bufPos]
-	} else {
-		err = io.EOF
-	}
-
+	rn, _, err = r.src.read(r.bufPos, r.bytePos)
 	return
 }
 
 // Read returns the next rune and advances the read position
 func (r *Reader) Read() (rn rune, err error) {
-	if r.bufPos < r.bufPosEnd {
-		rn = r.buf[r.bufPos]
-		r.bufPos++
+	rn, size, err := r.src.read(r.bufPos, r.bytePos)
+	if err != nil {
+		return 0, err
+	}
+
+	if r.ctx != nil && r.limits.MaxInputSize > 0 && r.bytePos+size > r.limits.MaxInputSize {
+		return 0, r.limitError(InputSizeLimit, nil)
+	}
+
+	r.bufPos++
+	r.bytePos += size
 
-		if r.bufPos < r.bufPosEnd && r.linePos < r.linePosEnd {
-			if r.bufPos >= r.lines[r.linePos] {
-				r.linePos++
+	if rn == '\n' || rn == '\r' {
+		// A new line starts after LF, CRLF or CR unless the line ending is at the end of the input
+		next, _, nextErr := r.src.read(r.bufPos, r.bytePos)
+		if nextErr == nil && (rn == '\n' || next != '\n') {
+			r.linePos++
+
+			if r.linePos-1-r.lineBase == len(r.lines) {
+				r.lines = append(r.lines, lineStart{pos: r.bufPos, off: r.bytePos})
 			}
 		}
-	} else {
-		err = io.EOF
 	}
 
-	return
+	// Discarding is attempted once per chunk of read input
+	if r.streaming && r.bufPos-r.discardPos >= discardChunk {
+		r.discard()
+	}
+
+	return rn, nil
 }
 
 // StringFromResult get string from match result
 func (r *Reader) StringFromResult(m *MatchResult) string {
-	return string(r.buf[m.BeginPos.absoluteCharPos:m.EndPos.absoluteCharPos])
+	return r.text(m.BeginPos.absoluteCharPos, m.BeginPos.byteOffset, m.EndPos.absoluteCharPos, m.EndPos.byteOffset)
+}
+
+// BytesFromResult gets the UTF-8 encoded input of a match result, for a reader created with NewBytesReader
+// the returned slice shares memory with the input
+func (r *Reader) BytesFromResult(m *MatchResult) []byte {
+	b, err := r.src.bytes(m.BeginPos.absoluteCharPos, m.BeginPos.byteOffset, m.EndPos.absoluteCharPos, m.EndPos.byteOffset)
+	if err != nil {
+		if r.err == nil {
+			r.err = err
+		}
+
+		return nil
+	}
+
+	return b
 }
 
 // PushError push match result errors
@@ -169,6 +325,11 @@ func (r *Reader) PushError(failed *MatchResult) {
 	r.errorStack = append(r.errorStack, failed)
 }
 
+// Errors returns the pushed match result errors in order
+func (r *Reader) Errors() []*MatchResult {
+	return append([]*MatchResult{}, r.errorStack...)
+}
+
 // DeepestError returns the error that is the most advanced in char pos
 func (r *Reader) DeepestError() *MatchResult {
 	var deepestResult *MatchResult = nil
@@ -0,0 +1,112 @@
+package ebnf
+
+import (
+	"errors"
+	"io"
+)
+
+// Recovery pattern, matches a pattern and recovers from a failed match by skipping input until
+// the synchronization pattern matches
+type Recovery struct {
+	BaseTransformer
+	Pattern Pattern
+	Sync    Pattern
+}
+
+// Recover creates a new recovery pattern, onError is called for the error node created when the
+// pattern fails to match
+func Recover(p Pattern, syncSet Pattern, onError TransformFunction) *Recovery {
+	return &Recovery{
+		BaseTransformer: BaseTransformer{
+			T: onError,
+		},
+		Pattern: p,
+		Sync:    syncSet,
+	}
+}
+
+// Match recovery pattern, returns the result of the pattern if it matches. Otherwise input is skipped
+// until the synchronization pattern matches (the synchronization match is consumed, wrap it in a
+// lookahead to keep it) or the end of input is reached. The skipped input results in an error node:
+// a match with MatchResult.Recovered set, MatchResult.Failed holding the failed match and
+// MatchResult.Error holding the *ParseError at the furthest failure in the pattern. Error nodes are
+// pushed on the error stack of the reader, note that errors of recoveries in alternatives which are
+// abandoned later on remain on the stack. If no input could be skipped the failed match is returned
+func (rec *Recovery) Match(r *Reader) (*MatchResult, error) {
+	return r.invoke(rec, rec.match)
+}
+
+func (rec *Recovery) match(r *Reader) (*MatchResult, error) {
+	beginPos := r.CurrentPosition()
+
+	result, err := rec.Pattern.Match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Match {
+		return result, nil
+	}
+
+	var matchErr error = result.Error
+	if parseErr := r.ParseError(); parseErr != nil && parseErr.Pos.absoluteCharPos >= beginPos.absoluteCharPos {
+		matchErr = parseErr
+	}
+
+	if matchErr == nil {
+		matchErr = errors.New("syntax error")
+	}
+
+	r.PushState()
+
+	for {
+		syncResult, err := rec.Sync.Match(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if syncResult.Match {
+			break
+		}
+
+		_, err = r.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.bufPos == beginPos.absoluteCharPos {
+		r.RestoreState()
+		return result, nil
+	}
+
+	r.PopState()
+
+	// Failures while skipping are not relevant for later errors
+	r.failPos = nil
+	r.expected = nil
+
+	errorNode := &MatchResult{
+		BeginPos:  beginPos,
+		EndPos:    r.CurrentPosition(),
+		Match:     true,
+		Recovered: true,
+		Error:     matchErr,
+		Failed:    result,
+	}
+
+	r.setNode(ErrorNode, errorNode, nil)
+
+	err = rec.Transform(errorNode, r)
+	if err != nil {
+		return nil, err
+	}
+
+	r.PushError(errorNode)
+
+	return errorNode, nil
+}
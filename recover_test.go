@@ -0,0 +1,88 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	statement := NewConcatenation(
+		[]Pattern{
+			NewRepetition(NewCharacterRange('a', 'z', false, nil), 1, 0, nil),
+			NewTerminalString("=", nil),
+			NewRepetition(NewCharacterRange('0', '9', false, nil), 1, 0, nil),
+			NewTerminalString(";", nil),
+		},
+		nil,
+	)
+
+	recovered := 0
+	program := NewConcatenation(
+		[]Pattern{
+			NewAny(Recover(statement, NewTerminalString(";", nil), func(m *MatchResult, r *Reader) error {
+				recovered++
+				m.Result = r.StringFromResult(m)
+				return nil
+			}), nil),
+			NewEOF(nil),
+		},
+		nil,
+	)
+
+	reader, _ := NewReader(strings.NewReader("a=1;b=;c=3;\nd=x;e=5;f"))
+
+	result, err := Parse(program, reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	statements := result.Result.([]*MatchResult)[0].Result.([]*MatchResult)
+	if len(statements) != 6 || recovered != 3 {
+		t.Fatalf("expected 6 statements with 3 recovered, got %d with %d recovered", len(statements), recovered)
+	}
+
+	if !statements[1].Recovered || statements[1].Result != "b=;" || statements[2].Recovered {
+		t.Errorf("unexpected statements %v %v", statements[1], statements[2])
+	}
+
+	errs := reader.Errors()
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 errors, got %d", len(errs))
+	}
+
+	expected := []string{
+		`expected '0'..'9' at line 1, col 7, found ';'`,
+		`expected 'a'..'z' at line 1, col 12, found '\n'`,
+		`expected "=" at line 2, col 10, found end of input`,
+	}
+
+	for i, e := range errs {
+		if e.Error.Error() != expected[i] {
+			t.Errorf("expected error %q, got %q", expected[i], e.Error.Error())
+		}
+	}
+}
+
+func TestRecoverWithoutProgress(t *testing.T) {
+	reader, _ := NewReader(strings.NewReader("}"))
+
+	result, err := Recover(NewTerminalString("a", nil), NewTerminalString("}", nil), nil).Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !result.Recovered || !reader.Finished() {
+		t.Errorf("expected recovery consuming the synchronization match")
+	}
+
+	reader, _ = NewReader(strings.NewReader(""))
+
+	result, err = Recover(NewTerminalString("a", nil), NewTerminalString("}", nil), nil).Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if result.Match || len(reader.Errors()) != 0 {
+		t.Errorf("expected failed match without recovery")
+	}
+}
@@ -0,0 +1,93 @@
+package ebnf
+
+// ruleKey identifies a rule invocation at a position
+type ruleKey struct {
+	grammar *Grammar
+	name    string
+	pos     int
+}
+
+// ruleCall holds the state of a rule invocation that is in progress, if the rule is invoked again
+// at the same position before it is finished the rule is left recursive and the seed is returned
+type ruleCall struct {
+	index         int
+	leftRecursive bool
+	seed          *MatchResult
+	seedPos       *ReaderPos
+}
+
+// matchRule matches the pattern of a named rule. Left recursion is detected when a rule is invoked
+// again at the same position before the first invocation is finished. The recursive invocation fails
+// first, then the seed is grown by matching the rule again and again at the same position with the
+// recursive invocation returning the previous (shorter) match, until the match can not be extended.
+// This results in left associative match trees for left recursive rules
+func (r *Reader) matchRule(g *Grammar, name string, p Pattern) (*MatchResult, error) {
+	key := ruleKey{grammar: g, name: name, pos: r.bufPos}
+
+	if call, ok := r.ruleCalls[key]; ok {
+		call.leftRecursive = true
+
+		// Results depending on the seed can not be memoized until the seed is fully grown
+		if r.leftRecursionHead == nil || call.index < r.leftRecursionHead.index {
+			r.leftRecursionHead = call
+		}
+
+		if call.seed == nil {
+			pos := r.CurrentPosition()
+			return &MatchResult{BeginPos: pos, EndPos: pos, Match: false}, nil
+		}
+
+		r.seek(call.seedPos)
+
+		result := *call.seed
+
+		return &result, nil
+	}
+
+	if r.ruleCalls == nil {
+		r.ruleCalls = map[ruleKey]*ruleCall{}
+	}
+
+	call := &ruleCall{index: len(r.ruleCalls)}
+	r.ruleCalls[key] = call
+
+	defer func() {
+		delete(r.ruleCalls, key)
+
+		if r.leftRecursionHead == call {
+			r.leftRecursionHead = nil
+		}
+	}()
+
+	beginPos := r.CurrentPosition()
+
+	result, err := p.Match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if !call.leftRecursive || !result.Match {
+		return result, nil
+	}
+
+	// Grow the seed
+	for {
+		call.seed = result
+		call.seedPos = r.CurrentPosition()
+
+		r.seek(beginPos)
+
+		result, err = p.Match(r)
+		if err != nil {
+			return nil, err
+		}
+
+		if !result.Match || r.bufPos <= call.seedPos.absoluteCharPos {
+			break
+		}
+	}
+
+	r.seek(call.seedPos)
+
+	return call.seed, nil
+}
@@ -0,0 +1,147 @@
+package ebnf
+
+import (
+	"io"
+	"regexp"
+	"sort"
+)
+
+// Regexp pattern, matches a regular expression at the current position
+type Regexp struct {
+	BaseTransformer
+	Regexp *regexp.Regexp
+	Name   string
+}
+
+// NewRegexp creates a new regular expression pattern, the expression is anchored at the current position
+// of the reader. Panics if the expression can not be compiled, like regexp.MustCompile
+func NewRegexp(expr string, t TransformFunction) *Regexp {
+	return &Regexp{
+		BaseTransformer: BaseTransformer{
+			T: t,
+		},
+		Regexp: regexp.MustCompile(`^(?:` + expr + `)`),
+		Name:   "/" + expr + "/",
+	}
+}
+
+// regexpInput reads the input of a reader from a position without moving the reader, it records the
+// runes that are read, their byte offsets relative to the begin position and read errors other than
+// the end of input
+type regexpInput struct {
+	reader  *Reader
+	pos     int
+	off     int
+	runes   []rune
+	offsets []int
+	err     error
+}
+
+// ReadRune implements io.RuneReader
+func (in *regexpInput) ReadRune() (rune, int, error) {
+	rn, size, err := in.reader.src.read(in.pos+len(in.runes), in.off)
+	if err != nil {
+		if err != io.EOF {
+			in.err = err
+		}
+
+		return 0, 0, err
+	}
+
+	if len(in.offsets) == 0 {
+		in.offsets = append(in.offsets, 0)
+	}
+
+	in.runes = append(in.runes, rn)
+	in.offsets = append(in.offsets, in.offsets[len(in.offsets)-1]+size)
+	in.off += size
+
+	return rn, size, nil
+}
+
+// runeIndex returns the number of runes before a byte offset
+func (in *regexpInput) runeIndex(off int) int {
+	return sort.SearchInts(in.offsets, off)
+}
+
+// Match regular expression, MatchResult.Result will contain []string with the matched input followed by
+// the input matched by the groups of the expression (an empty string for a group that did not match)
+func (re *Regexp) Match(r *Reader) (*MatchResult, error) {
+	beginPos := r.CurrentPosition()
+
+	result := &MatchResult{Match: false}
+	result.BeginPos = beginPos
+
+	in := &regexpInput{
+		reader: r,
+		pos:    r.bufPos,
+		off:    r.bytePos,
+	}
+
+	indices := re.Regexp.FindReaderSubmatchIndex(in)
+
+	if in.err != nil {
+		return nil, in.err
+	}
+
+	if indices == nil {
+		r.expect(beginPos, re.Name)
+
+		result.EndPos = beginPos
+
+		err := re.Transform(result, r)
+		if err != nil {
+			return nil, err
+		}
+
+		return result, nil
+	}
+
+	groups := make([]string, len(indices)/2)
+
+	for i := range groups {
+		if indices[2*i] >= 0 {
+			groups[i] = string(in.runes[in.runeIndex(indices[2*i]):in.runeIndex(indices[2*i+1])])
+		}
+	}
+
+	// Advance the reader so lines are tracked
+	r.PushState()
+
+	for n := in.runeIndex(indices[1]); n > 0; n-- {
+		_, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.Match = true
+	result.EndPos = r.CurrentPosition()
+	result.Result = groups
+
+	r.setNode(TerminalNode, result, nil)
+
+	err := re.Transform(result, r)
+	if err != nil {
+		return nil, err
+	}
+
+	r.PopState()
+
+	return result, nil
+}
+
+// Group returns the input matched by a named group of the expression from a match result of the pattern
+func (re *Regexp) Group(m *MatchResult, name string) string {
+	groups, ok := m.Result.([]string)
+	if !ok {
+		return ""
+	}
+
+	index := re.Regexp.SubexpIndex(name)
+	if index < 0 || index >= len(groups) {
+		return ""
+	}
+
+	return groups[index]
+}
@@ -0,0 +1,61 @@
+package ebnf
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestRegexp(t *testing.T) {
+	number := NewRegexp(`(?P<int>-?(?:0|[1-9][0-9]*))(?:\.(?P<frac>[0-9]+))?(?:[eE](?P<exp>[+-]?[0-9]+))?`, nil)
+	assignment := NewConcatenation([]Pattern{
+		NewRegexp(`[\p{L}_][\p{L}\p{N}_]*`, nil),
+		NewRegexp(`\s*=\s*`, nil),
+		number,
+		NewTerminalString(";", nil),
+	}, nil)
+
+	reader, _ := NewReader(strings.NewReader("größe =\n  -12.5e3;"))
+
+	result, err := assignment.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() {
+		t.Fatalf("expected assignment to match all input")
+	}
+
+	params := result.Result.([]*MatchResult)
+
+	if name := params[0].Result.([]string)[0]; name != "größe" {
+		t.Errorf("unexpected name %q", name)
+	}
+
+	m := params[2]
+	if number.Group(m, "int") != "-12" || number.Group(m, "frac") != "5" || number.Group(m, "exp") != "3" {
+		t.Errorf("unexpected groups %q", m.Result)
+	}
+
+	if m.BeginPos.Line() != 2 || m.BeginPos.Column() != 3 || m.EndPos.Offset() != 17 || m.EndPos.ByteOffset() != 19 {
+		t.Errorf("unexpected positions %v-%v", m.BeginPos, m.EndPos)
+	}
+
+	if value, _ := strconv.ParseFloat(reader.StringFromResult(m), 64); value != -12500 {
+		t.Errorf("unexpected value %v", value)
+	}
+
+	reader, _ = NewReader(strings.NewReader("x = 01;"))
+
+	_, err = Parse(assignment, reader)
+	if err == nil || !strings.HasPrefix(err.Error(), `expected ";" at line 1, col 6`) {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	reader, _ = NewReader(strings.NewReader("x = a;"))
+
+	_, err = Parse(assignment, reader)
+	if err == nil || !strings.HasPrefix(err.Error(), "expected /(?P<int>") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,158 @@
+package ebnf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ANSI escape sequences used for colored snippets
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[1;31m"
+	ansiBlue  = "\x1b[1;34m"
+)
+
+// lineText returns the text of a line without line ending, returns nil if the line has been discarded
+func (r *Reader) lineText(line int) []rune {
+	start, ok := r.lineStart(line)
+	if !ok {
+		return nil
+	}
+
+	text := []rune{}
+	pos := start.pos
+	off := start.off
+
+	for {
+		rn, size, err := r.src.read(pos, off)
+		if err == ErrDiscarded {
+			return nil
+		}
+
+		if err != nil || rn == '\n' || rn == '\r' {
+			break
+		}
+
+		text = append(text, rn)
+		pos++
+		off += size
+	}
+
+	return text
+}
+
+// Snippet renders the source lines between begin and end with the span underlined by carets, preceded by
+// the message (if not empty) and the position of the span, for instance:
+//
+//	error: expected "," or "}", found '"'
+//	 --> line 3, col 2
+//	  |
+//	3 |  "c": 3}
+//	  |  ^
+//
+// An empty span is marked by a single caret. If color is true ANSI escape sequences are added. Lines
+// discarded by a streaming reader are replaced by a note and ErrDiscarded is returned by Reader.Err
+func (r *Reader) Snippet(begin *ReaderPos, end *ReaderPos, message string, color bool) string {
+	var builder strings.Builder
+
+	paint := func(style string, s string) string {
+		if color {
+			return style + s + ansiReset
+		}
+		return s
+	}
+
+	if message != "" {
+		fmt.Fprintf(&builder, "%s%s\n", paint(ansiRed, "error"), paint(ansiBold, ": "+message))
+	}
+
+	lastLine := end.linePos
+	if lastLine > begin.linePos && end.relativeCharPos == 0 {
+		// The span ends at the start of a line, do not show that line
+		lastLine--
+	}
+
+	gutter := len(strconv.Itoa(lastLine + 1))
+	emptyGutter := strings.Repeat(" ", gutter)
+
+	fmt.Fprintf(&builder, "%s%s line %d, col %d\n", emptyGutter, paint(ansiBlue, "-->"), begin.linePos+1, begin.relativeCharPos+1)
+	fmt.Fprintf(&builder, "%s %s\n", emptyGutter, paint(ansiBlue, "|"))
+
+	for line := begin.linePos; line <= lastLine; line++ {
+		text := r.lineText(line)
+
+		if text == nil {
+			// Lines discarded by a streaming reader are summarized by a single note
+			first := line
+			for line < lastLine {
+				if _, ok := r.lineStart(line + 1); ok {
+					break
+				}
+
+				line++
+			}
+
+			note := fmt.Sprintf("line %d discarded", first+1)
+			if line > first {
+				note = fmt.Sprintf("lines %d-%d discarded", first+1, line+1)
+			}
+
+			if r.err == nil {
+				r.err = ErrDiscarded
+			}
+
+			fmt.Fprintf(&builder, "%s %s %s\n", emptyGutter, paint(ansiBlue, "|"), paint(ansiBold, "("+note+")"))
+
+			continue
+		}
+
+		from := 0
+		if line == begin.linePos {
+			from = begin.relativeCharPos
+		}
+
+		to := len(text)
+		if line == end.linePos {
+			to = end.relativeCharPos
+		}
+
+		if to <= from {
+			to = from + 1
+		}
+
+		// Indent the carets with the whitespace of the line so tabs line up
+		var indent strings.Builder
+		for i := 0; i < from; i++ {
+			if i < len(text) && text[i] == '\t' {
+				indent.WriteRune('\t')
+			} else {
+				indent.WriteRune(' ')
+			}
+		}
+
+		number := strconv.Itoa(line + 1)
+
+		fmt.Fprintf(&builder, "%s%s %s %s\n", strings.Repeat(" ", gutter-len(number)), paint(ansiBlue, number), paint(ansiBlue, "|"), string(text))
+		fmt.Fprintf(&builder, "%s %s %s%s\n", emptyGutter, paint(ansiBlue, "|"), indent.String(), paint(ansiRed, strings.Repeat("^", to-from)))
+	}
+
+	return builder.String()
+}
+
+// FormatResult renders a snippet for the range of a match result with the error of the match result
+// as message
+func (r *Reader) FormatResult(m *MatchResult, color bool) string {
+	message := ""
+	if m.Error != nil {
+		message = m.Error.Error()
+	}
+
+	return r.Snippet(m.BeginPos, m.EndPos, message, color)
+}
+
+// FormatParseError renders a snippet for a parse error with a caret at the error position
+func (r *Reader) FormatParseError(e *ParseError, color bool) string {
+	return r.Snippet(e.Pos, e.Pos, e.Message(), color)
+}
@@ -0,0 +1,98 @@
+package ebnf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFormatParseError(t *testing.T) {
+	reader, _ := NewReader(strings.NewReader("{\"a\": 1,\n\t\"b\": 2\n\t\"c\": 3}"))
+
+	_, err := Parse(jsonGrammar(), reader)
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+
+	expected := `error: expected one of " \n\r\t", "," or "}", found '"'
+ --> line 3, col 2
+  |
+3 | 	"c": 3}
+  | 	^
+`
+
+	if snippet := reader.FormatParseError(err.(*ParseError), false); snippet != expected {
+		t.Errorf("unexpected snippet:\n%s", snippet)
+	}
+
+	if snippet := reader.FormatParseError(err.(*ParseError), true); !strings.Contains(snippet, ansiRed+"^"+ansiReset) {
+		t.Errorf("expected colored snippet:\n%s", snippet)
+	}
+}
+
+func TestFormatResult(t *testing.T) {
+	reader, _ := NewReader(strings.NewReader("a := [1,\n  2,\n  3] + b"))
+
+	list := NewConcatenation(
+		[]Pattern{
+			NewTerminalString("a := ", nil),
+			NewTerminalString("[", nil),
+			NewAny(NewCharacterEnum("123, \n", false, nil), nil),
+			NewTerminalString("]", nil),
+		},
+		nil,
+	)
+
+	result, err := list.Match(reader)
+	if err != nil || !result.Match {
+		t.Fatalf("expected match")
+	}
+
+	result.BeginPos = result.Result.([]*MatchResult)[1].BeginPos
+	result.Error = errors.New("lists are not allowed")
+
+	expected := `error: lists are not allowed
+ --> line 1, col 6
+  |
+1 | a := [1,
+  |      ^^^
+2 |   2,
+  | ^^^^
+3 |   3] + b
+  | ^^^^
+`
+
+	if snippet := reader.FormatResult(result, false); snippet != expected {
+		t.Errorf("unexpected snippet:\n%s", snippet)
+	}
+}
+
+func TestSnippetDiscarded(t *testing.T) {
+	var third *ReaderPos
+
+	statement := NewConcatenation([]Pattern{
+		NewRepetition(NewCharacterRange('a', 'z', false, nil), 1, 0, nil),
+		NewTerminalString(";\n", nil),
+	}, func(m *MatchResult, r *Reader) error {
+		if m.Match && m.EndPos.Line() == 4 && third == nil {
+			third = m.EndPos
+		}
+		return nil
+	})
+
+	reader := NewStreamingReader(strings.NewReader(strings.Repeat("statement;\n", 2000)))
+
+	result, err := NewAny(statement, nil).Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	snippet := reader.Snippet(result.BeginPos, third, "", false)
+	if snippet != " --> line 1, col 1\n  |\n  | (lines 1-3 discarded)\n" {
+		t.Errorf("unexpected snippet %q", snippet)
+	}
+
+	if reader.Err() != ErrDiscarded {
+		t.Errorf("expected ErrDiscarded, got %v", reader.Err())
+	}
+}
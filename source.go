@@ -0,0 +1,138 @@
+package ebnf
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// ErrDiscarded is returned when input is requested which has already been discarded by a streaming reader
+var ErrDiscarded = errors.New("input has been discarded")
+
+// source provides the input of a reader, positions are passed as rune position and byte offset
+type source interface {
+	// read returns the rune at a position and the size of the rune in bytes, returns io.EOF at the end
+	read(pos int, off int) (rune, int, error)
+	// text returns the input between two positions
+	text(begin int, beginOff int, end int, endOff int) (string, error)
+	// bytes returns the UTF-8 encoded input between two positions
+	bytes(begin int, beginOff int, end int, endOff int) ([]byte, error)
+	// discard releases the input before a position
+	discard(pos int, off int)
+}
+
+// runeSource holds all input runes
+type runeSource []rune
+
+func (s runeSource) read(pos int, off int) (rune, int, error) {
+	if pos >= len(s) {
+		return 0, 0, io.EOF
+	}
+
+	return s[pos], utf8.RuneLen(s[pos]), nil
+}
+
+func (s runeSource) text(begin int, beginOff int, end int, endOff int) (string, error) {
+	return string(s[begin:end]), nil
+}
+
+func (s runeSource) bytes(begin int, beginOff int, end int, endOff int) ([]byte, error) {
+	return []byte(string(s[begin:end])), nil
+}
+
+func (s runeSource) discard(pos int, off int) {
+}
+
+// byteSource decodes runes from UTF-8 encoded input when they are read, invalid bytes are read as
+// utf8.RuneError with a size of one byte
+type byteSource []byte
+
+func (s byteSource) read(pos int, off int) (rune, int, error) {
+	if off >= len(s) {
+		return 0, 0, io.EOF
+	}
+
+	rn, size := utf8.DecodeRune(s[off:])
+
+	return rn, size, nil
+}
+
+func (s byteSource) text(begin int, beginOff int, end int, endOff int) (string, error) {
+	return string(s[beginOff:endOff]), nil
+}
+
+func (s byteSource) bytes(begin int, beginOff int, end int, endOff int) ([]byte, error) {
+	return s[beginOff:endOff:endOff], nil
+}
+
+func (s byteSource) discard(pos int, off int) {
+}
+
+// streamSource reads runes from an io.Reader when they are needed and keeps a window of runes
+// starting at base
+type streamSource struct {
+	reader *bufio.Reader
+	buf    []rune
+	base   int
+	err    error
+}
+
+func (s *streamSource) read(pos int, off int) (rune, int, error) {
+	if pos < s.base {
+		return 0, 0, ErrDiscarded
+	}
+
+	for pos-s.base >= len(s.buf) {
+		if s.err != nil {
+			return 0, 0, s.err
+		}
+
+		rn, _, err := s.reader.ReadRune()
+		if err != nil {
+			s.err = err
+			return 0, 0, err
+		}
+
+		s.buf = append(s.buf, rn)
+	}
+
+	rn := s.buf[pos-s.base]
+
+	return rn, utf8.RuneLen(rn), nil
+}
+
+func (s *streamSource) text(begin int, beginOff int, end int, endOff int) (string, error) {
+	if begin < s.base {
+		return "", ErrDiscarded
+	}
+
+	if end > begin {
+		_, _, err := s.read(end-1, 0)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return string(s.buf[begin-s.base : end-s.base]), nil
+}
+
+func (s *streamSource) bytes(begin int, beginOff int, end int, endOff int) ([]byte, error) {
+	text, err := s.text(begin, beginOff, end, endOff)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(text), nil
+}
+
+func (s *streamSource) discard(pos int, off int) {
+	n := pos - s.base
+	if n <= 0 {
+		return
+	}
+
+	// Copy the remaining window so the memory of the discarded runes is released
+	s.buf = append(make([]rune, 0, len(s.buf)-n+discardChunk), s.buf[n:]...)
+	s.base = pos
+}
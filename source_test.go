@@ -0,0 +1,58 @@
+package ebnf
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestBytesReader(t *testing.T) {
+	input := []byte("héllo\nwörld 😃!")
+
+	word := NewRepetition(NewCharacterGroup(func(rn rune) bool { return rn != ' ' && rn != '\n' }, false, nil), 1, 0, nil)
+	pattern := NewConcatenation([]Pattern{
+		word,
+		NewTerminalString("\n", nil),
+		NewTerminalString("wörld", nil),
+		NewTerminalString(" ", nil),
+		word,
+	}, nil)
+
+	reader := NewBytesReader(input)
+
+	result, err := pattern.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() {
+		t.Fatalf("expected pattern to match all input")
+	}
+
+	params := result.Result.([]*MatchResult)
+
+	if s := reader.StringFromResult(params[4]); s != "😃!" {
+		t.Errorf("unexpected string %q", s)
+	}
+
+	b := reader.BytesFromResult(params[2])
+	if string(b) != "wörld" || &b[0] != &input[7] {
+		t.Errorf("expected slice of the input, got %q", b)
+	}
+
+	if pos := params[4].BeginPos; pos.Offset() != 12 || pos.ByteOffset() != 14 || pos.Line() != 2 || pos.Column() != 7 {
+		t.Errorf("unexpected position %v, offset %d, byte offset %d", pos, pos.Offset(), pos.ByteOffset())
+	}
+
+	// Invalid UTF-8 is read byte by byte
+	reader = NewBytesReader([]byte{'a', 0xff, 'b'})
+
+	for _, expected := range []rune{'a', utf8.RuneError, 'b'} {
+		if rn, err := reader.Read(); err != nil || rn != expected {
+			t.Errorf("expected %q, got %q", expected, rn)
+		}
+	}
+
+	if !reader.Finished() || reader.CurrentPosition().ByteOffset() != 3 {
+		t.Errorf("expected reader to be finished at byte offset 3")
+	}
+}
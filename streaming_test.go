@@ -0,0 +1,107 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamingReader(t *testing.T) {
+	input := strings.Repeat("key=value;\n", 20000)
+	count := 0
+
+	statement := NewConcatenation([]Pattern{
+		NewRepetition(NewCharacterRange('a', 'z', false, nil), 1, 0, nil),
+		NewTerminalString("=", nil),
+		NewRepetition(NewCharacterRange('a', 'z', false, nil), 1, 0, nil),
+		NewTerminalString(";\n", nil),
+	}, func(m *MatchResult, r *Reader) error {
+		if m.Match && r.StringFromResult(m) == "key=value;\n" {
+			count++
+		}
+		return nil
+	})
+
+	reader := NewStreamingReader(strings.NewReader(input))
+
+	result, err := NewAny(statement, nil).Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() || count != 20000 {
+		t.Fatalf("expected all statements to match, got %d", count)
+	}
+
+	if n := len(reader.src.(*streamSource).buf); n > 2*discardChunk {
+		t.Errorf("expected input to be discarded, %d runes buffered", n)
+	}
+
+	if pos := reader.CurrentPosition(); pos.Line() != 20000 || pos.Column() != 12 || pos.ByteOffset() != len(input) {
+		t.Errorf("unexpected end position %v", pos)
+	}
+
+	// Only the text of the complete repetition has been discarded
+	reader = NewStreamingReader(strings.NewReader(input))
+
+	_, err = NewAny(statement, func(m *MatchResult, r *Reader) error {
+		m.Result = r.StringFromResult(m)
+		return nil
+	}).Match(reader)
+	if err != ErrDiscarded {
+		t.Errorf("expected ErrDiscarded, got %v", err)
+	}
+}
+
+func TestStreamingReaderBacktracking(t *testing.T) {
+	// The concatenation holds its begin position so the alternation can backtrack to it
+	long := strings.Repeat("a", 3*discardChunk)
+	pattern := NewAlternation([]Pattern{
+		NewConcatenation([]Pattern{NewAny(NewTerminalString("a", nil), nil), NewTerminalString("b", nil)}, nil),
+		NewConcatenation([]Pattern{NewAny(NewTerminalString("a", nil), nil), NewTerminalString("c", nil)}, nil),
+	}, nil)
+
+	reader := NewStreamingReader(strings.NewReader(long + "c"))
+
+	result, err := pattern.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || !reader.Finished() || len(reader.StringFromResult(result)) != len(long)+1 {
+		t.Errorf("expected alternation to backtrack over the complete input")
+	}
+}
+
+func TestStreamingReaderConcatenation(t *testing.T) {
+	// The top level concatenation holds its begin position, so nothing can be discarded while matching.
+	// Discarding is only attempted once per chunk, instead of on every read with a sweep of the memo
+	input := strings.Repeat("key=value;\n", 20000)
+
+	statement := NewConcatenation([]Pattern{
+		NewRepetition(NewCharacterRange('a', 'z', false, nil), 1, 0, nil),
+		NewTerminalString("=", nil),
+		NewRepetition(NewCharacterRange('a', 'z', false, nil), 1, 0, nil),
+		NewTerminalString(";\n", nil),
+	}, nil)
+
+	pattern := NewConcatenation([]Pattern{NewAny(statement, nil), NewEOF(nil)}, nil)
+
+	reader := NewStreamingReader(strings.NewReader(input))
+	reader.SetMemoization(true)
+
+	start := time.Now()
+
+	result, err := pattern.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	if !result.Match || reader.discarded != 0 {
+		t.Fatalf("expected concatenation to match without discarding input")
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("matching took %v", elapsed)
+	}
+}
@@ -0,0 +1,132 @@
+package ebnf
+
+import (
+	"strings"
+)
+
+// Trivia pattern, marks the input matched by a pattern, for instance whitespace and comments, as trivia.
+// In the syntax tree trivia is attached to the adjacent tokens instead of being a child node (see
+// Node.AttachTrivia)
+type Trivia struct {
+	BaseTransformer
+	Pattern Pattern
+}
+
+// NewTrivia creates a new trivia pattern
+func NewTrivia(p Pattern, t TransformFunction) *Trivia {
+	return &Trivia{
+		BaseTransformer: BaseTransformer{
+			T: t,
+		},
+		Pattern: p,
+	}
+}
+
+// Match trivia pattern, returns the match result of the pattern. If the syntax tree is enabled the
+// result holds a trivia node without children
+func (tr *Trivia) Match(r *Reader) (*MatchResult, error) {
+	result, err := tr.Pattern.Match(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Match {
+		r.setNode(TriviaNode, result, nil)
+	}
+
+	err = tr.Transform(result, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// AttachTrivia removes the trivia nodes from the tree and attaches them to the tokens, the leaf nodes
+// that are not trivia. Trivia that starts on the line where the previous token ends is attached as
+// trailing trivia to that token, other trivia is attached as leading trivia to the next token. Trivia
+// after the last token is attached as trailing trivia to the last token. Empty trivia is dropped.
+// Parse attaches the trivia of the syntax tree, after which the FullText of the root node reproduces
+// the matched input
+func (n *Node) AttachTrivia() {
+	if n == nil {
+		return
+	}
+
+	var previous *Node
+	pending := []*Node{}
+
+	var attach func(node *Node)
+	attach = func(node *Node) {
+		children := []*Node{}
+
+		for _, child := range node.Children {
+			if child.Kind == TriviaNode {
+				if child.Span.Len() == 0 {
+					continue
+				}
+
+				if previous != nil && len(pending) == 0 && child.Span.Begin.linePos == previous.Span.End.linePos {
+					previous.Trailing = append(previous.Trailing, child)
+				} else {
+					pending = append(pending, child)
+				}
+
+				continue
+			}
+
+			children = append(children, child)
+
+			if len(child.Children) == 0 && child.Span.Len() > 0 {
+				child.Leading = append(child.Leading, pending...)
+				pending = []*Node{}
+				previous = child
+			} else {
+				attach(child)
+			}
+		}
+
+		node.Children = children
+	}
+
+	attach(n)
+
+	if previous != nil {
+		previous.Trailing = append(previous.Trailing, pending...)
+	} else {
+		// Without tokens the trivia is kept as children
+		n.Children = append(n.Children, pending...)
+	}
+}
+
+// FullText returns the input matched by the node including the attached trivia
+func (n *Node) FullText() string {
+	var b strings.Builder
+
+	n.writeFullText(&b)
+
+	return b.String()
+}
+
+// writeFullText writes the input matched by the node including the attached trivia
+func (n *Node) writeFullText(b *strings.Builder) {
+	if n == nil {
+		return
+	}
+
+	for _, trivia := range n.Leading {
+		b.WriteString(trivia.Text())
+	}
+
+	if len(n.Children) == 0 {
+		b.WriteString(n.Text())
+	} else {
+		for _, child := range n.Children {
+			child.writeFullText(b)
+		}
+	}
+
+	for _, trivia := range n.Trailing {
+		b.WriteString(trivia.Text())
+	}
+}
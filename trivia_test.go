@@ -0,0 +1,70 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestTrivia(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		document = ws, value, ws ;
+		value = array | number ;
+		array = "[", ws, [ value, ws, { ",", ws, value, ws } ], "]" ;
+		number = digit, { digit } ;
+		digit = "0" | "1" | "2" | "3" ;
+		ws = ? white space ? ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	comment := NewConcatenation([]Pattern{
+		NewTerminalString("//", nil),
+		NewAny(NewCharacterEnum("\n", true, nil), nil),
+	}, nil)
+
+	grammar.Rule("white space", NewTrivia(NewAny(NewAlternation([]Pattern{
+		NewRepetition(NewCharacterGroup(unicode.IsSpace, false, nil), 1, 0, nil),
+		comment,
+	}, nil), nil), nil))
+
+	input := "  // numbers\n[ 1,\n  23 , // last\n  [ ] ]\n"
+
+	reader, _ := NewReader(strings.NewReader(input))
+	reader.SetSyntaxTree(true)
+
+	result, err := Parse(grammar, reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	root := result.Node
+
+	if text := root.FullText(); text != input {
+		t.Errorf("expected full text to reproduce the input, got %q", text)
+	}
+
+	// Trivia is not part of the children
+	array := root.ChildByRule("value").ChildByRule("array")
+	if array == nil || len(array.Children) != 3 {
+		t.Fatalf("unexpected array node %+v", array)
+	}
+
+	open := array.Child(0)
+	if open.Text() != "[" || len(open.Leading) != 1 || open.Leading[0].Text() != "  // numbers\n" || len(open.Trailing) != 1 {
+		t.Errorf("unexpected trivia of %q", open.Text())
+	}
+
+	// The optional items hold the first value and the repetition of comma separated values
+	items := array.Child(1).Child(0)
+	comma := items.Child(1).Child(1).Child(0)
+	if comma.Text() != "," || len(comma.Trailing) != 1 || comma.Trailing[0].Text() != " // last\n  " {
+		t.Errorf("unexpected trailing trivia of %q", comma.Text())
+	}
+
+	last := array.Child(2)
+	if last.Text() != "]" || len(last.Trailing) != 1 || last.Trailing[0].Text() != "\n" {
+		t.Errorf("unexpected trivia of the last token")
+	}
+}
@@ -0,0 +1,111 @@
+package ebnf
+
+// Visitor is called for each match result visited by Walk. If Visit returns a non nil visitor w, the
+// children of the match result are walked with w, followed by a call of w.Visit(nil)
+type Visitor interface {
+	Visit(m *MatchResult) (w Visitor)
+}
+
+// children returns the child match results of a match result, the results of Concatenation,
+// Repetition and OperatorTable patterns hold []*MatchResult unless a transform replaced them and
+// the inner result of a rule is the only child of the rule result
+func children(m *MatchResult) []*MatchResult {
+	if m.Inner != nil {
+		return []*MatchResult{m.Inner}
+	}
+
+	results, ok := m.Result.([]*MatchResult)
+	if !ok {
+		return nil
+	}
+
+	return results
+}
+
+// Walk traverses a match result tree in depth first order. It starts by calling v.Visit(m), if the
+// returned visitor w is nil the children are skipped. Otherwise Walk is called with w for each non
+// nil child, followed by a call of w.Visit(nil) which can be used for post order processing
+func Walk(m *MatchResult, v Visitor) {
+	if v = v.Visit(m); v == nil {
+		return
+	}
+
+	for _, child := range children(m) {
+		if child != nil {
+			Walk(child, v)
+		}
+	}
+
+	v.Visit(nil)
+}
+
+// inspector is the visitor of Inspect
+type inspector func(m *MatchResult) bool
+
+// Visit calls the inspect function for a match result
+func (f inspector) Visit(m *MatchResult) Visitor {
+	if f(m) {
+		return f
+	}
+
+	return nil
+}
+
+// Inspect traverses a match result tree in depth first order, f is called for each match result and
+// the children are skipped if f returns false. After the children f is called with nil
+func Inspect(m *MatchResult, f func(m *MatchResult) bool) {
+	Walk(m, inspector(f))
+}
+
+// Rewrite rewrites a match result tree bottom up, f is called for each match result after its
+// children are rewritten and returns the replacement of the match result, or nil to remove it from
+// the children of its parent. A match result with rewritten children is copied, so match results
+// shared with other trees (for instance memoized results) are not modified. A rule result with a
+// rewritten inner result takes over the result of the replacement. Returns the rewritten root
+func Rewrite(m *MatchResult, f func(m *MatchResult) *MatchResult) *MatchResult {
+	if m == nil {
+		return nil
+	}
+
+	if m.Inner != nil {
+		if replacement := Rewrite(m.Inner, f); replacement != m.Inner {
+			copied := *m
+			copied.Inner = replacement
+			copied.Result = nil
+
+			if replacement != nil {
+				copied.Result = replacement.Result
+			}
+
+			m = &copied
+		}
+
+		return f(m)
+	}
+
+	results := children(m)
+
+	if results != nil {
+		rewritten := make([]*MatchResult, 0, len(results))
+		changed := false
+
+		for _, child := range results {
+			replacement := Rewrite(child, f)
+			if replacement != child {
+				changed = true
+			}
+
+			if replacement != nil {
+				rewritten = append(rewritten, replacement)
+			}
+		}
+
+		if changed {
+			copied := *m
+			copied.Result = rewritten
+			m = &copied
+		}
+	}
+
+	return f(m)
+}
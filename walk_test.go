@@ -0,0 +1,115 @@
+package ebnf
+
+import (
+	"strings"
+	"testing"
+)
+
+// ruleCounter counts rule matches and tracks the depth of the walk
+type ruleCounter struct {
+	counts map[string]int
+	depth  int
+}
+
+func (c *ruleCounter) Visit(m *MatchResult) Visitor {
+	if m == nil {
+		c.depth--
+		return nil
+	}
+
+	// Nested lists are not visited
+	if params, ok := m.Result.([]*MatchResult); ok && m.Rule == "item" && params[0].Result == "(" {
+		c.counts["nested"]++
+		return nil
+	}
+
+	if m.Rule != "" {
+		c.counts[m.Rule]++
+	}
+
+	c.depth++
+
+	return c
+}
+
+func TestWalk(t *testing.T) {
+	grammar, err := ParseGrammar(strings.NewReader(`
+		list = "(", [ item, { ",", item } ], ")" ;
+		item = number | list ;
+		number = digit, { digit } ;
+		digit = "0" | "1" | "2" ;
+	`))
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	reader, _ := NewReader(strings.NewReader("(1,(20,(1)),2)"))
+
+	result, err := Parse(grammar, reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	counter := &ruleCounter{counts: map[string]int{}}
+	Walk(result, counter)
+
+	// Items wrap the result of the number rule
+	if counter.counts["list"] != 1 || counter.counts["item"] != 2 || counter.counts["number"] != 2 || counter.counts["nested"] != 1 || counter.counts["digit"] != 2 || counter.depth != 0 {
+		t.Errorf("unexpected counts %v", counter.counts)
+	}
+
+	digits := []string{}
+	Inspect(result, func(m *MatchResult) bool {
+		if m != nil && m.Rule == "digit" {
+			digits = append(digits, m.Result.(string))
+		}
+		return true
+	})
+
+	if strings.Join(digits, "") != "12012" {
+		t.Errorf("unexpected digits %v", digits)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	pattern := NewRepetition(NewAlternation([]Pattern{
+		NewTerminalString("a", nil),
+		NewTerminalString("b", nil),
+		NewConcatenation([]Pattern{NewTerminalString("(", nil), NewTerminalString("c", nil), NewTerminalString(")", nil)}, nil),
+	}, nil), 0, 0, nil)
+
+	reader, _ := NewReader(strings.NewReader("ab(c)a"))
+
+	result, err := pattern.Match(reader)
+	if err != nil {
+		t.Fatalf("err %v", err)
+	}
+
+	original := result.Result.([]*MatchResult)
+
+	// Remove b and replace parenthesized expressions by their content
+	rewritten := Rewrite(result, func(m *MatchResult) *MatchResult {
+		if m.Result == "b" {
+			return nil
+		}
+
+		if params, ok := m.Result.([]*MatchResult); ok && len(params) == 3 && params[0].Result == "(" {
+			return params[1]
+		}
+
+		return m
+	})
+
+	values := []string{}
+	for _, m := range rewritten.Result.([]*MatchResult) {
+		values = append(values, m.Result.(string))
+	}
+
+	if strings.Join(values, "") != "aca" {
+		t.Errorf("unexpected rewritten values %v", values)
+	}
+
+	if rewritten == result || len(result.Result.([]*MatchResult)) != len(original) {
+		t.Errorf("expected original result to be unchanged")
+	}
+}